@@ -0,0 +1,54 @@
+package irc
+
+import (
+	"log"
+)
+
+// Batch is a group of Messages received between "BATCH +ref type parms..."
+// and "BATCH -ref". Nested Batches are Messages in the parent with Batch set.
+type Batch struct {
+	Ref      string
+	Type     string
+	Parms    Parms
+	Messages []Message
+}
+
+// collectBatch buffers m if it belongs to a BATCH. It returns the Message to
+// deliver, for a completed outermost BATCH that is the "BATCH +ref" Message
+// with Batch set. ok is false while m got buffered.
+func (c *Client) collectBatch(m Message) (_ Message, ok bool) {
+	if m.Command == "BATCH" && len(m.Parms) > 0 && len(m.Parms[0]) > 1 {
+		ref := m.Parms[0][1:]
+		switch m.Parms[0][0] {
+		case '+':
+			b := &Batch{Ref: ref}
+			if len(m.Parms) > 1 {
+				b.Type = m.Parms[1]
+				b.Parms = append(b.Parms, m.Parms[2:]...)
+			}
+			if m.Trailing != "" {
+				b.Parms = append(b.Parms, m.Trailing)
+			}
+			m.Batch = b
+			c.batches[ref] = m
+			return Message{}, false
+
+		case '-':
+			start, ok := c.batches[ref]
+			if !ok {
+				log.Print("end of unknown batch ", ref)
+				return Message{}, false
+			}
+			delete(c.batches, ref)
+			m = start
+		}
+	}
+
+	if ref, ok := m.Tags["batch"]; ok {
+		if parent, ok := c.batches[ref]; ok {
+			parent.Batch.Messages = append(parent.Batch.Messages, m)
+			return Message{}, false
+		}
+	}
+	return m, true
+}
@@ -0,0 +1,139 @@
+package irc
+
+import (
+	"log"
+	"strings"
+)
+
+// supportedCaps are the capabilities the Client requests if the server offers them
+var supportedCaps = []string{
+	"account-notify",
+	"away-notify",
+	"batch",
+	"chghost",
+	"draft/multiline",
+	"echo-message",
+	"extended-join",
+	"labeled-response",
+	"message-tags",
+	"server-time",
+}
+
+// CapEnabled reports if the capability name was acknowledged by the server
+func (c *Client) CapEnabled(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capsEnabled[name]
+}
+
+// CapValue returns the value of the capability name as advertised by the server
+func (c *Client) CapValue(name string) (value string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok = c.caps[name]
+	return value, ok
+}
+
+// capStart starts the capability negotiation, the server delays the
+// registration till capEnd
+func (c *Client) capStart() {
+	c.mu.Lock()
+	c.caps = make(map[string]string)
+	c.capsEnabled = make(map[string]bool)
+	c.capNegotiating = true
+	c.mu.Unlock()
+	c.send <- Message{
+		Command: "CAP",
+		Parms:   Parms{"LS", "302"},
+	}
+}
+
+func (c *Client) capEnd() {
+	c.mu.Lock()
+	negotiating := c.capNegotiating
+	c.capNegotiating = false
+	c.mu.Unlock()
+	if negotiating {
+		c.send <- Message{Command: "CAP", Parms: Parms{"END"}}
+	}
+}
+
+// capRequest requests the supported capabilities out of list
+func (c *Client) capRequest(list []string) {
+	var req []string
+	for _, name := range list {
+		for _, sup := range supportedCaps {
+			if name == sup {
+				req = append(req, name)
+			}
+		}
+	}
+	if len(req) == 0 {
+		c.capEnd()
+		return
+	}
+	c.send <- Message{
+		Command:  "CAP",
+		Parms:    Parms{"REQ"},
+		Trailing: strings.Join(req, " "),
+	}
+}
+
+// handleCap handles the CAP replies of the server
+// ":server CAP <nick> <subcommand> [*] :<caps>"
+func (c *Client) handleCap(m Message) {
+	if len(m.Parms) < 2 {
+		return
+	}
+	list := strings.Fields(m.Trailing)
+	if m.Trailing == "" && len(m.Parms) > 2 {
+		list = strings.Fields(m.Parms[len(m.Parms)-1])
+	}
+	names := make([]string, 0, len(list))
+
+	c.mu.Lock()
+	switch m.Parms[1] {
+	case "LS", "NEW":
+		for _, cp := range list {
+			kv := strings.SplitN(cp, "=", 2)
+			kv = append(kv, "")
+			c.caps[kv[0]] = kv[1]
+			if m.Parms[1] == "NEW" {
+				names = append(names, kv[0])
+			}
+		}
+		if m.Parms[1] == "LS" {
+			for name := range c.caps {
+				names = append(names, name)
+			}
+		}
+	case "DEL":
+		for _, name := range list {
+			delete(c.caps, name)
+			delete(c.capsEnabled, name)
+		}
+	case "ACK":
+		for _, name := range list {
+			if strings.HasPrefix(name, "-") {
+				delete(c.capsEnabled, name[1:])
+				continue
+			}
+			c.capsEnabled[name] = true
+		}
+		log.Print("enabled caps: ", list)
+	}
+	c.mu.Unlock()
+
+	switch m.Parms[1] {
+	case "LS":
+		// "*" marks that more LS replies follow
+		if len(m.Parms) > 2 && m.Parms[2] == "*" {
+			return
+		}
+		c.capRequest(names)
+	case "NEW":
+		c.capRequest(names)
+	case "ACK", "NAK":
+		c.capEnd()
+	}
+}
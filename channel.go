@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 )
 
 // Mode represents a IRC mode
@@ -40,6 +41,15 @@ func (m Mode) SetMode(s string) error {
 	return nil
 }
 
+// copy returns a copy of m
+func (m Mode) copy() Mode {
+	c := make(Mode, len(m))
+	for k := range m {
+		c[k] = struct{}{}
+	}
+	return c
+}
+
 func (m Mode) String() string {
 	if len(m) <= 0 {
 		return ""
@@ -104,9 +114,22 @@ func (c *Channel) Part() {
 	}
 }
 
+// User is what is known about a nick in the joined Channels. It is kept
+// current by the away-notify, account-notify, extended-join and chghost
+// capabilities.
+type User struct {
+	Nick, User, Host string
+	Account          string // empty if not logged in
+	Realname         string
+	Away             bool
+	AwayMessage      string
+}
+
 // ChannelManager ...
 type ChannelManager struct {
+	mu             sync.Mutex // guards channels, users and nick
 	channels       map[string]*Channel
+	users          map[string]*User
 	send           chan<- Message
 	nick           string
 	DefaultHandler Handler
@@ -114,23 +137,83 @@ type ChannelManager struct {
 
 // NewCM returns a new ChannelManager listening on CLient
 func NewCM(cl *Client) *ChannelManager {
+	cl.mu.Lock()
+	nick := cl.nick
+	cl.mu.Unlock()
 	cm := &ChannelManager{
 		channels:       make(map[string]*Channel),
+		users:          make(map[string]*User),
 		send:           cl.send,
-		nick:           cl.nick,
+		nick:           nick,
 		DefaultHandler: defaultHandler,
 	}
 	return cm
 }
 
+// User returns what is known about nick
+func (cm *ChannelManager) User(nick string) (User, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	u, ok := cm.users[nick]
+	if !ok {
+		return User{}, false
+	}
+	return *u, true
+}
+
+// user returns the User record of nick and creates it if needed
+func (cm *ChannelManager) user(nick string) *User {
+	u, ok := cm.users[nick]
+	if !ok {
+		u = &User{Nick: nick}
+		cm.users[nick] = u
+	}
+	return u
+}
+
+// forget removes the User record of nick if it is in no joined Channel
+func (cm *ChannelManager) forget(nick string) {
+	for _, ch := range cm.channels {
+		if _, ok := ch.nicks[nick]; ok {
+			return
+		}
+	}
+	delete(cm.users, nick)
+}
+
+// lastParm returns the last parameter of m
+func lastParm(m Message) string {
+	if ps := m.Params(); len(ps) > 0 {
+		return ps[len(ps)-1]
+	}
+	return ""
+}
+
 func (cm *ChannelManager) chControl(req Message, res chan<- Message) bool {
 	switch req.Command {
 	case "JOIN":
+		// "JOIN <channel>" or with extended-join "JOIN <channel> <account> :<realname>"
+		name := req.Trailing
+		if len(req.Parms) > 0 {
+			name = req.Parms[0]
+		}
+		if name == "" {
+			return false
+		}
+		u := cm.user(req.Prefix.Nick)
+		u.User, u.Host = req.Prefix.User, req.Prefix.Host
+		if len(req.Parms) > 1 {
+			u.Account, u.Realname = req.Parms[1], req.Trailing
+			if u.Account == "*" {
+				u.Account = ""
+			}
+		}
+
 		if req.Prefix.Nick == cm.nick {
-			if _, ok := cm.channels[req.Parms[0]]; !ok {
-				log.Print("join channel ", req.Parms[0])
-				cm.channels[req.Parms[0]] = &Channel{
-					name:   req.Parms[0],
+			if _, ok := cm.channels[name]; !ok {
+				log.Print("join channel ", name)
+				cm.channels[name] = &Channel{
+					name:   name,
 					nicks:  make(map[string]Mode),
 					cMode:  make(Mode),
 					myMode: make(Mode),
@@ -139,21 +222,30 @@ func (cm *ChannelManager) chControl(req Message, res chan<- Message) bool {
 
 			return true
 		}
-		if ch, ok := cm.channels[req.Parms[0]]; ok {
-			log.Printf("%q joins %q", req.Prefix.Nick, req.Parms[0])
+		if ch, ok := cm.channels[name]; ok {
+			log.Printf("%q joins %q", req.Prefix.Nick, name)
 			ch.nicks[req.Prefix.Nick] = Mode{}
 			return true
 		}
+		cm.forget(req.Prefix.Nick)
 
 	case "PART":
+		name := lastParm(req)
+		if len(req.Parms) > 0 {
+			name = req.Parms[0]
+		}
 		if req.Prefix.Nick == cm.nick {
-			log.Print("left channel ", req.Parms[0])
-			delete(cm.channels, req.Parms[0])
+			log.Print("left channel ", name)
+			delete(cm.channels, name)
+			for nick := range cm.users {
+				cm.forget(nick)
+			}
 			return true
 		}
-		if ch, ok := cm.channels[req.Parms[0]]; ok {
-			log.Printf("%q left %q", req.Prefix.Nick, req.Parms[0])
+		if ch, ok := cm.channels[name]; ok {
+			log.Printf("%q left %q", req.Prefix.Nick, name)
 			delete(ch.nicks, req.Prefix.Nick)
+			cm.forget(req.Prefix.Nick)
 			return true
 		}
 
@@ -164,8 +256,34 @@ func (cm *ChannelManager) chControl(req Message, res chan<- Message) bool {
 				delete(ch.nicks, req.Prefix.Nick)
 			}
 		}
+		delete(cm.users, req.Prefix.Nick)
 		return true
 
+	case "AWAY":
+		// away-notify: "AWAY :<message>" or "AWAY" when back
+		if u, ok := cm.users[req.Prefix.Nick]; ok {
+			u.AwayMessage = lastParm(req)
+			u.Away = u.AwayMessage != ""
+			return true
+		}
+
+	case "ACCOUNT":
+		// account-notify: "ACCOUNT <account>" or "ACCOUNT *" on logout
+		if u, ok := cm.users[req.Prefix.Nick]; ok {
+			u.Account = lastParm(req)
+			if u.Account == "*" {
+				u.Account = ""
+			}
+			return true
+		}
+
+	case "CHGHOST":
+		// chghost: "CHGHOST <new user> <new host>"
+		if u, ok := cm.users[req.Prefix.Nick]; ok && len(req.Parms) > 0 {
+			u.User, u.Host = req.Parms[0], lastParm(req)
+			return true
+		}
+
 	case "MODE":
 		if ch, ok := cm.channels[req.Parms[0]]; ok {
 			switch {
@@ -186,22 +304,30 @@ func (cm *ChannelManager) chControl(req Message, res chan<- Message) bool {
 		return false
 
 	case "NICK":
-		if nick := req.Prefix.Nick; nick != "" {
+		if nick, newNick := req.Prefix.Nick, lastParm(req); nick != "" && newNick != "" {
 			for _, ch := range cm.channels {
 				if m, ok := ch.nicks[nick]; ok {
-					ch.nicks[req.Trailing] = m
+					ch.nicks[newNick] = m
 					delete(ch.nicks, nick)
 				}
 			}
+			if u, ok := cm.users[nick]; ok {
+				u.Nick = newNick
+				cm.users[newNick] = u
+				delete(cm.users, nick)
+			}
+			if nick == cm.nick {
+				cm.nick = newNick
+			}
 		}
 
-	case ircRplTOPIC:
+	case RplTOPIC:
 		if ch, ok := cm.channels[req.Parms[0]]; ok {
 			ch.topic = req.Trailing
 			return true
 		}
 
-	case ircRplNAMREPLY:
+	case RplNAMREPLY:
 		if ch, ok := cm.channels[req.Parms[2]]; ok {
 			for _, ni := range strings.Fields(req.Trailing) {
 				m := Mode{}
@@ -215,12 +341,13 @@ func (cm *ChannelManager) chControl(req Message, res chan<- Message) bool {
 				default:
 				}
 				ch.nicks[ni] = m
+				cm.user(ni)
 			}
 			return true
 		}
 
-	case ircErrBANNEDFROMCHAN:
-		log.Print(req.Parms[0] + ": " + req.Trailing)
+	case ErrBANNEDFROMCHAN:
+		log.Print(req.Err())
 
 	default:
 	}
@@ -228,18 +355,46 @@ func (cm *ChannelManager) chControl(req Message, res chan<- Message) bool {
 	return false
 }
 
-// List lists joined Channels
+// batchControl runs chControl on all Messages in b
+func (cm *ChannelManager) batchControl(b *Batch, res chan<- Message) {
+	for _, m := range b.Messages {
+		if m.Batch != nil {
+			cm.batchControl(m.Batch, res)
+			continue
+		}
+		cm.chControl(m, res)
+	}
+}
+
+// List lists copies of the joined Channels
 func (cm *ChannelManager) List() []Channel {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
 	cl := []Channel{}
 	for _, ch := range cm.channels {
-		cl = append(cl, *ch)
+		c := *ch
+		c.nicks = make(map[string]Mode, len(ch.nicks))
+		for nick, m := range ch.nicks {
+			c.nicks[nick] = m.copy()
+		}
+		c.cMode, c.myMode = ch.cMode.copy(), ch.myMode.copy()
+		cl = append(cl, c)
 	}
 	return cl
 }
 
 // ServeIRC implaments Handler
 func (cm *ChannelManager) ServeIRC(req Message, res chan<- Message) bool {
-	if cm.chControl(req, res) {
+	cm.mu.Lock()
+	handled := false
+	if req.Batch != nil {
+		// e.g. the QUITs of a netsplit
+		cm.batchControl(req.Batch, res)
+	} else {
+		handled = cm.chControl(req, res)
+	}
+	cm.mu.Unlock()
+	if handled {
 		return true
 	}
 	return cm.DefaultHandler.ServeIRC(req, res)
@@ -0,0 +1,122 @@
+package irc
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset converts between a legacy character set and UTF-8
+type Charset interface {
+	Decode(b []byte) string
+	Encode(s string) []byte
+}
+
+// Legacy single byte character sets
+var (
+	// Latin1 is ISO-8859-1
+	Latin1 Charset = newSingleByte(nil)
+	// Latin9 is ISO-8859-15
+	Latin9 Charset = newSingleByte(map[byte]rune{
+		0xa4: '€', 0xa6: 'Š', 0xa8: 'š', 0xb4: 'Ž', 0xb8: 'ž', 0xbc: 'Œ', 0xbd: 'œ', 0xbe: 'Ÿ',
+	})
+	// CP1252 is Windows-1252
+	CP1252 Charset = newSingleByte(map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+	})
+)
+
+// singleByte is a character set that maps every byte to one rune, the bytes
+// not in the table map to the rune with the same value like in Latin-1
+type singleByte struct {
+	decode [256]rune
+	encode map[rune]byte
+}
+
+func newSingleByte(table map[byte]rune) *singleByte {
+	cs := &singleByte{encode: make(map[rune]byte)}
+	for i := range cs.decode {
+		r, ok := table[byte(i)]
+		if !ok {
+			r = rune(i)
+		}
+		cs.decode[i] = r
+		cs.encode[r] = byte(i)
+	}
+	return cs
+}
+
+// Decode implaments Charset
+func (cs *singleByte) Decode(b []byte) string {
+	rs := make([]rune, len(b))
+	for i, c := range b {
+		rs[i] = cs.decode[c]
+	}
+	return string(rs)
+}
+
+// Encode implaments Charset, runes not in the character set become "?"
+func (cs *singleByte) Encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		c, ok := cs.encode[r]
+		if !ok {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// decodeString returns s if it is valid UTF-8 or decodes it with cs
+func decodeString(s string, cs Charset) string {
+	if cs == nil || utf8.ValidString(s) {
+		return s
+	}
+	return cs.Decode([]byte(s))
+}
+
+// SetChannelCharset sets the Charset used for channel instead of
+// Config.Fallback and Config.Encoding, nil removes it
+func (c *Client) SetChannelCharset(channel string, cs Charset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cs == nil {
+		delete(c.charsets, strings.ToLower(channel))
+		return
+	}
+	c.charsets[strings.ToLower(channel)] = cs
+}
+
+// charset returns the Charset for m or def
+func (c *Client) charset(m Message, def Charset) Charset {
+	if len(m.Parms) == 0 {
+		return def
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cs, ok := c.charsets[strings.ToLower(m.Parms[0])]; ok {
+		return cs
+	}
+	return def
+}
+
+// decode converts the fields of m that are not valid UTF-8 with the fallback Charset
+func (c *Client) decode(m *Message) {
+	if utf8.Valid(m.Raw) {
+		return
+	}
+	cs := c.charset(*m, c.cfg.Fallback)
+	m.Prefix.Nick = decodeString(m.Prefix.Nick, cs)
+	m.Prefix.User = decodeString(m.Prefix.User, cs)
+	m.Prefix.Host = decodeString(m.Prefix.Host, cs)
+	for i := range m.Parms {
+		m.Parms[i] = decodeString(m.Parms[i], cs)
+	}
+	m.Trailing = decodeString(m.Trailing, cs)
+	for k, v := range m.Tags {
+		m.Tags[k] = decodeString(v, cs)
+	}
+}
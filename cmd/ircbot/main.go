@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/juggle-tux/irc"
+	"github.com/juggle-tux/irc/format"
 )
 
 // flags
@@ -39,9 +40,11 @@ func ResHandler(req irc.Message, res chan<- irc.Message) bool {
 	case "MODE":
 		log.Print(req)
 	case "PRIVMSG":
-		str := strings.Replace(req.Trailing, "\x02", "", -1)
-		str = strings.Replace(str, "\x03", "", -1)
-		fmt.Printf("%q<->%q: %q\n", req.Parms[0], req.Prefix.Nick, str)
+		if req.Self {
+			break
+		}
+		str := format.Strip(req.Trailing)
+		fmt.Printf("%s %q<->%q: %q\n", req.Time.Local().Format("15:04:05"), req.Parms[0], req.Prefix.Nick, str)
 		switch req.Parms[0] {
 		// Privat message
 		case *clNick:
@@ -84,16 +87,30 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close()
+	defer conn.Close("bye")
 	cm := irc.NewCM(conn)
 	cm.DefaultHandler = irc.HandlerFunc(ResHandler)
-	conn.Handle(cm)
+	ctcp := irc.NewCTCPResponder(conn)
+	ctcp.DefaultHandler = cm
+	conn.Handle(ctcp)
+	go func() {
+		for e := range conn.Events {
+			switch e := e.(type) {
+			case irc.CTCPEvent:
+				log.Printf("CTCP %s from %q", e.Command, e.Message.Prefix.Nick)
+			case irc.StandardReplyEvent:
+				log.Print(e.Error())
+			case irc.RetryEvent:
+				log.Printf("reconnecting to %s in %s", e.Address, e.Delay)
+			}
+		}
+	}()
 	for _, ch := range flag.Args() {
 		conn.Send(irc.Join(ch))
 	}
 
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt, os.Kill)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
 
 	go func() {
 		for _, open := <-conn.Msg; open; _, open = <-conn.Msg {
@@ -0,0 +1,110 @@
+package irc
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// DefaultMaxLength is the default maximal length of a line read by a Decoder
+// without the line ending
+const DefaultMaxLength = MaxTagsLength + MaxLineLength
+
+// ErrLineTooLong is returned by Decoder.Decode for a line exceeding MaxLength,
+// the line is skipped and the next Decode continues with the following one
+var ErrLineTooLong = errors.New("line too long")
+
+// Decoder reads Messages from an io.Reader. Lines may end with "\r\n" or a
+// bare "\n", empty lines are skipped.
+type Decoder struct {
+	// MaxLength is the maximal length of a line without the line ending,
+	// changes after the first Decode have no effect
+	MaxLength int
+
+	r   io.Reader
+	buf *bufio.Reader
+	p   Parser
+}
+
+// NewDecoder returns a new Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		MaxLength: DefaultMaxLength,
+		r:         r,
+	}
+}
+
+// readLine returns the next line without line ending
+func (d *Decoder) readLine() ([]byte, error) {
+	if d.buf == nil {
+		d.buf = bufio.NewReaderSize(d.r, d.MaxLength+len("\r\n"))
+	}
+	line, err := d.buf.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		for err == bufio.ErrBufferFull {
+			_, err = d.buf.ReadSlice('\n')
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, ErrLineTooLong
+	}
+	if err == io.EOF && len(line) > 0 {
+		// last line without line ending
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	if len(line) > d.MaxLength {
+		return nil, ErrLineTooLong
+	}
+	return line, nil
+}
+
+// Decode reads and parses the next Message, Raw is set to the line
+func (d *Decoder) Decode() (Message, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return Message{}, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+		m, err := d.p.Parse(line)
+		m.Raw = line
+		return m.Clone(), err
+	}
+}
+
+// Encoder writes Messages to an io.Writer
+type Encoder struct {
+	// Charset encodes the lines, nil writes UTF-8
+	Charset Charset
+
+	w io.Writer
+}
+
+// NewEncoder returns a new Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode validates m and writes it as a line terminated by "\r\n"
+func (e *Encoder) Encode(m Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	var err error
+	if e.Charset == nil {
+		_, err = io.WriteString(e.w, m.String())
+	} else {
+		_, err = e.w.Write(e.Charset.Encode(m.String()))
+	}
+	return err
+}
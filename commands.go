@@ -0,0 +1,415 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The constructors below build the client Messages of RFC 2812. Their
+// arguments are checked by Validate and Client.Send, e.g. a nick containing a
+// space is rejected instead of being send as trailing parameter.
+
+// optional returns the parameters up to the first empty one
+func optional(ps ...string) []string {
+	for i, p := range ps {
+		if p == "" {
+			ps = ps[:i]
+			break
+		}
+	}
+	if len(ps) == 0 {
+		return nil
+	}
+	return ps
+}
+
+// middle returns a Message with parms as middle parameters, a last parameter
+// that would need to be a trailing one makes the Message invalid
+func middle(command string, parms ...string) Message {
+	m := Message{Command: command, Parms: Parms(parms)}
+	if n := len(parms); n > 0 && needsTrailing(parms[n-1]) {
+		// an empty trailing keeps the parameter in the middle for Validate
+		m.HasTrailing = true
+	}
+	return m
+}
+
+// text returns a Message with the free text str as trailing parameter
+func text(command, str string, parms ...string) Message {
+	return Message{
+		Command:     command,
+		Parms:       Parms(parms),
+		Trailing:    str,
+		HasTrailing: true,
+	}
+}
+
+// Pass creates a PASS message with the connection password
+func Pass(password string) Message {
+	return middle("PASS", password)
+}
+
+// Nick creates a NICK message to change the nickname
+func Nick(nick string) Message {
+	return middle("NICK", nick)
+}
+
+// Register creates the USER message of the connection registration
+func Register(user, realname string) Message {
+	return text("USER", realname, user, "0", "*")
+}
+
+// Oper creates an OPER message to become an IRC operator
+func Oper(name, password string) Message {
+	return middle("OPER", name, password)
+}
+
+// SetMode creates a MODE message setting modes with their arguments on target
+// (channel or nick). Use ModeQuery to ask for the modes.
+func SetMode(target, modes string, args ...string) Message {
+	return middle("MODE", append([]string{target, modes}, args...)...)
+}
+
+// ModeQuery creates a MODE message asking for the modes of target
+func ModeQuery(target string) Message {
+	return middle("MODE", target)
+}
+
+// Deop creates a MODE message to set "-o" to nick in channel
+func Deop(nick, channel string) Message {
+	return SetMode(channel, "-o", nick)
+}
+
+// Voice creates a MODE message to set "+v" to nick in channel
+func Voice(nick, channel string) Message {
+	return SetMode(channel, "+v", nick)
+}
+
+// Devoice creates a MODE message to set "-v" to nick in channel
+func Devoice(nick, channel string) Message {
+	return SetMode(channel, "-v", nick)
+}
+
+// Ban creates a MODE message to set "+b" with mask in channel
+func Ban(mask, channel string) Message {
+	return SetMode(channel, "+b", mask)
+}
+
+// Unban creates a MODE message to set "-b" with mask in channel
+func Unban(mask, channel string) Message {
+	return SetMode(channel, "-b", mask)
+}
+
+// Service creates a SERVICE message to register a service
+func Service(nick, distribution, typ, info string) Message {
+	return text("SERVICE", info, nick, "*", distribution, typ, "0")
+}
+
+// Quit creates a QUIT message with an optional reason
+func Quit(reason string) Message {
+	if reason == "" {
+		return middle("QUIT")
+	}
+	return text("QUIT", reason)
+}
+
+// Squit creates a SQUIT message to disconnect server
+func Squit(server, comment string) Message {
+	return text("SQUIT", comment, server)
+}
+
+// JoinKey creates a JOIN message to join channel with key
+func JoinKey(channel, key string) Message {
+	return middle("JOIN", optional(channel, key)...)
+}
+
+// JoinAll creates the JOIN messages to join channels, keys[i] is the key of
+// channels[i] and may be missing or empty. The channels are split over as
+// few Messages as the TARGMAX limit of JOIN in is and the line length allow.
+func JoinAll(is ISupport, channels, keys []string) []Message {
+	// channels with a key have to come first
+	var keyed, plain []string
+	var ks []string
+	for i, ch := range channels {
+		if i < len(keys) && keys[i] != "" {
+			keyed = append(keyed, ch)
+			ks = append(ks, keys[i])
+		} else {
+			plain = append(plain, ch)
+		}
+	}
+	channels = append(keyed, plain...)
+
+	max := is.TargMax("JOIN")
+	var ms []Message
+	for len(channels) > 0 {
+		n, length := 0, len("JOIN \r\n")
+		for n < len(channels) && (max == 0 || n < max) {
+			l := len(channels[n]) + len(",")
+			if n < len(ks) {
+				l += len(ks[n]) + len(",")
+			}
+			if n > 0 && length+l > MaxLineLength {
+				break
+			}
+			length += l
+			n++
+		}
+		m := middle("JOIN", strings.Join(channels[:n], ","))
+		if k := len(ks); k > 0 {
+			if k > n {
+				k = n
+			}
+			m.Parms = append(m.Parms, strings.Join(ks[:k], ","))
+			ks = ks[k:]
+		}
+		ms = append(ms, m)
+		channels = channels[n:]
+	}
+	return ms
+}
+
+// JoinZero creates a "JOIN 0" message to leave all channels
+func JoinZero() Message {
+	return Join("0")
+}
+
+// Part creates a PART message to leave channel with an optional reason
+func Part(channel, reason string) Message {
+	if reason == "" {
+		return middle("PART", channel)
+	}
+	return text("PART", reason, channel)
+}
+
+// Topic creates a TOPIC message asking for the topic of channel
+func Topic(channel string) Message {
+	return middle("TOPIC", channel)
+}
+
+// SetTopic creates a TOPIC message to set the topic of channel, an empty
+// topic clears it
+func SetTopic(channel, topic string) Message {
+	return text("TOPIC", topic, channel)
+}
+
+// Names creates a NAMES message for channels or all visible channels
+func Names(channels ...string) Message {
+	return middle("NAMES", optional(strings.Join(channels, ","))...)
+}
+
+// List creates a LIST message for channels or all channels
+func List(channels ...string) Message {
+	return middle("LIST", optional(strings.Join(channels, ","))...)
+}
+
+// Invite creates an INVITE message to invite nick to channel
+func Invite(nick, channel string) Message {
+	return middle("INVITE", nick, channel)
+}
+
+// Kick creates a KICK message to remove nick from channel with an optional
+// reason
+func Kick(nick, channel, reason string) Message {
+	if reason == "" {
+		return middle("KICK", channel, nick)
+	}
+	return text("KICK", reason, channel, nick)
+}
+
+// Notice creates a NOTICE to recv (channel/nick) with the content of str
+func Notice(recv, str string) Message {
+	return text("NOTICE", str, recv)
+}
+
+// Motd creates a MOTD message for the optional target server
+func Motd(target string) Message {
+	return middle("MOTD", optional(target)...)
+}
+
+// Lusers creates a LUSERS message with an optional mask and target server
+func Lusers(mask, target string) Message {
+	return middle("LUSERS", optional(mask, target)...)
+}
+
+// Version creates a VERSION message for the optional target server
+func Version(target string) Message {
+	return middle("VERSION", optional(target)...)
+}
+
+// Stats creates a STATS message with an optional query and target server
+func Stats(query, target string) Message {
+	return middle("STATS", optional(query, target)...)
+}
+
+// Links creates a LINKS message with an optional mask and remote server
+func Links(remote, mask string) Message {
+	if remote == "" {
+		return middle("LINKS", optional(mask)...)
+	}
+	return middle("LINKS", optional(remote, mask)...)
+}
+
+// Time creates a TIME message for the optional target server
+func Time(target string) Message {
+	return middle("TIME", optional(target)...)
+}
+
+// Connect creates a CONNECT message to connect target on port to the optional
+// remote server
+func Connect(target string, port int, remote string) Message {
+	if port <= 0 {
+		return middle("CONNECT", target)
+	}
+	return middle("CONNECT", optional(target, strconv.Itoa(port), remote)...)
+}
+
+// Trace creates a TRACE message for the optional target
+func Trace(target string) Message {
+	return middle("TRACE", optional(target)...)
+}
+
+// Admin creates an ADMIN message for the optional target server
+func Admin(target string) Message {
+	return middle("ADMIN", optional(target)...)
+}
+
+// Info creates an INFO message for the optional target server
+func Info(target string) Message {
+	return middle("INFO", optional(target)...)
+}
+
+// Servlist creates a SERVLIST message with an optional mask and type
+func Servlist(mask, typ string) Message {
+	return middle("SERVLIST", optional(mask, typ)...)
+}
+
+// Squery creates a SQUERY message to service with the content of str
+func Squery(service, str string) Message {
+	return text("SQUERY", str, service)
+}
+
+// Who creates a WHO message for mask, with op only operators are returned
+func Who(mask string, op bool) Message {
+	if op {
+		return middle("WHO", optional(mask, "o")...)
+	}
+	return middle("WHO", optional(mask)...)
+}
+
+// Whois creates a WHOIS message for nick
+func Whois(nick string) Message {
+	return middle("WHOIS", nick)
+}
+
+// Whowas creates a WHOWAS message for nick, a count of 0 returns all entries
+func Whowas(nick string, count int) Message {
+	if count <= 0 {
+		return middle("WHOWAS", nick)
+	}
+	return middle("WHOWAS", nick, strconv.Itoa(count))
+}
+
+// Kill creates a KILL message to disconnect nick with comment
+func Kill(nick, comment string) Message {
+	return text("KILL", comment, nick)
+}
+
+// Ping creates a PING message with token
+func Ping(token string) Message {
+	return middle("PING", token)
+}
+
+// Pong creates a PONG message answering the PING with token
+func Pong(token string) Message {
+	return middle("PONG", token)
+}
+
+// Away creates an AWAY message with msg, an empty msg marks us as back
+func Away(msg string) Message {
+	if msg == "" {
+		return middle("AWAY")
+	}
+	return text("AWAY", msg)
+}
+
+// Rehash creates a REHASH message
+func Rehash() Message {
+	return middle("REHASH")
+}
+
+// Die creates a DIE message
+func Die() Message {
+	return middle("DIE")
+}
+
+// Restart creates a RESTART message
+func Restart() Message {
+	return middle("RESTART")
+}
+
+// Summon creates a SUMMON message for user with an optional target server and
+// channel
+func Summon(user, target, channel string) Message {
+	return middle("SUMMON", optional(user, target, channel)...)
+}
+
+// Users creates a USERS message for the optional target server
+func Users(target string) Message {
+	return middle("USERS", optional(target)...)
+}
+
+// Wallops creates a WALLOPS message with the content of str
+func Wallops(str string) Message {
+	return text("WALLOPS", str)
+}
+
+// Userhost creates a USERHOST message for up to 5 nicks, more make it invalid
+func Userhost(nicks ...string) Message {
+	return middle("USERHOST", nicks...)
+}
+
+// Ison creates an ISON message for nicks
+func Ison(nicks ...string) Message {
+	return text("ISON", strings.Join(nicks, " "))
+}
+
+// MassMode creates the MODE messages to set mode (e.g. "+o") with each of args
+// in channel, as many as the MODES limit in is and the parameter limit allow in
+// one Message
+func MassMode(is ISupport, channel, mode string, args ...string) []Message {
+	if len(mode) < 2 {
+		return nil
+	}
+	max := is.Modes()
+	if max == 0 || max > maxParms-2 {
+		// the channel and the modes take two parameters
+		max = maxParms - 2
+	}
+	var ms []Message
+	for len(args) > 0 {
+		n, length := 0, len("MODE  +\r\n")+len(channel)
+		for n < len(args) && n < max {
+			l := len(" ") + len(args[n]) + len(mode) - 1
+			if n > 0 && length+l > MaxLineLength {
+				break
+			}
+			length += l
+			n++
+		}
+		modes := mode[:1] + strings.Repeat(mode[1:], n)
+		ms = append(ms, SetMode(channel, modes, args[:n]...))
+		args = args[n:]
+	}
+	return ms
+}
+
+// MassOp creates the MODE messages to set "+o" to nicks in channel
+func MassOp(is ISupport, channel string, nicks ...string) []Message {
+	return MassMode(is, channel, "+o", nicks...)
+}
+
+// MassVoice creates the MODE messages to set "+v" to nicks in channel
+func MassVoice(is ISupport, channel string, nicks ...string) []Message {
+	return MassMode(is, channel, "+v", nicks...)
+}
@@ -0,0 +1,126 @@
+package irc
+
+import (
+	"testing"
+)
+
+// splitTests are taken from msg-split.yaml of the irc-parser-tests corpus
+// https://github.com/ircdocs/parser-tests
+var splitTests = []struct {
+	input  string
+	tags   Tags
+	source string
+	verb   string
+	params []string
+}{
+	{input: "foo bar baz asdf", verb: "foo", params: []string{"bar", "baz", "asdf"}},
+	{input: ":coolguy foo bar baz asdf", source: "coolguy", verb: "foo", params: []string{"bar", "baz", "asdf"}},
+	{input: "foo bar baz :asdf quux", verb: "foo", params: []string{"bar", "baz", "asdf quux"}},
+	{input: "foo bar baz :", verb: "foo", params: []string{"bar", "baz", ""}},
+	{input: "foo bar baz ::asdf", verb: "foo", params: []string{"bar", "baz", ":asdf"}},
+	{input: ":coolguy foo bar baz :asdf quux", source: "coolguy", verb: "foo", params: []string{"bar", "baz", "asdf quux"}},
+	{input: ":coolguy foo bar baz :  asdf quux ", source: "coolguy", verb: "foo", params: []string{"bar", "baz", "  asdf quux "}},
+	{input: ":coolguy PRIVMSG bar :lol :) ", source: "coolguy", verb: "PRIVMSG", params: []string{"bar", "lol :) "}},
+	{input: ":coolguy foo bar baz :", source: "coolguy", verb: "foo", params: []string{"bar", "baz", ""}},
+	{input: ":coolguy foo bar baz :  ", source: "coolguy", verb: "foo", params: []string{"bar", "baz", "  "}},
+	{input: "@a=b;c=32;k;rt=ql7 foo", tags: Tags{"a": "b", "c": "32", "k": "", "rt": "ql7"}, verb: "foo"},
+	{input: "@a=b\\\\and\\nk;c=72\\s45;d=gh\\:764 foo", tags: Tags{"a": "b\\and\nk", "c": "72 45", "d": "gh;764"}, verb: "foo"},
+	{input: "@c;h=;a=b :quux ab cd", tags: Tags{"c": "", "h": "", "a": "b"}, source: "quux", verb: "ab", params: []string{"cd"}},
+	{input: ":src JOIN #chan", source: "src", verb: "JOIN", params: []string{"#chan"}},
+	{input: ":src JOIN :#chan", source: "src", verb: "JOIN", params: []string{"#chan"}},
+	{input: ":src AWAY", source: "src", verb: "AWAY"},
+	{input: ":src AWAY ", source: "src", verb: "AWAY"},
+	{input: ":cool\tguy foo bar baz", source: "cool\tguy", verb: "foo", params: []string{"bar", "baz"}},
+	{input: ":coolguy!ag@net\x035w\x03ork.admin PRIVMSG foo :bar baz", source: "coolguy!ag@net\x035w\x03ork.admin", verb: "PRIVMSG", params: []string{"foo", "bar baz"}},
+	{input: ":coolguy!~ag@n\x02et\x0305w\x0fork.admin PRIVMSG foo :bar baz", source: "coolguy!~ag@n\x02et\x0305w\x0fork.admin", verb: "PRIVMSG", params: []string{"foo", "bar baz"}},
+	{input: "@tag1=value1;tag2;vendor1/tag3=value2;vendor2/tag4= :irc.example.com COMMAND param1 param2 :param3 param3", tags: Tags{"tag1": "value1", "tag2": "", "vendor1/tag3": "value2", "vendor2/tag4": ""}, source: "irc.example.com", verb: "COMMAND", params: []string{"param1", "param2", "param3 param3"}},
+	{input: ":irc.example.com COMMAND param1 param2 :param3 param3", source: "irc.example.com", verb: "COMMAND", params: []string{"param1", "param2", "param3 param3"}},
+	{input: "@tag1=value1;tag2;vendor1/tag3=value2;vendor2/tag4 COMMAND param1 param2 :param3 param3", tags: Tags{"tag1": "value1", "tag2": "", "vendor1/tag3": "value2", "vendor2/tag4": ""}, verb: "COMMAND", params: []string{"param1", "param2", "param3 param3"}},
+	{input: "COMMAND", verb: "COMMAND"},
+	{input: "@foo=\\\\\\\\\\:\\\\s\\s\\r\\n COMMAND", tags: Tags{"foo": "\\\\;\\s \r\n"}, verb: "COMMAND"},
+	{input: ":gravel.mozilla.org 432  #momo :Erroneous Nickname: Illegal characters", source: "gravel.mozilla.org", verb: "432", params: []string{"#momo", "Erroneous Nickname: Illegal characters"}},
+	{input: ":gravel.mozilla.org MODE #tckk +n ", source: "gravel.mozilla.org", verb: "MODE", params: []string{"#tckk", "+n"}},
+	{input: ":services.esper.net MODE #foo-bar +o foobar  ", source: "services.esper.net", verb: "MODE", params: []string{"#foo-bar", "+o", "foobar"}},
+	{input: "@tag1=value\\\\ntest COMMAND", tags: Tags{"tag1": "value\\ntest"}, verb: "COMMAND"},
+	{input: "@tag1=value\\1 COMMAND", tags: Tags{"tag1": "value1"}, verb: "COMMAND"},
+	{input: "@tag1=value1\\ COMMAND", tags: Tags{"tag1": "value1"}, verb: "COMMAND"},
+	{input: "@tag1=1;tag2=3;tag3=4;tag1=5 COMMAND", tags: Tags{"tag1": "5", "tag2": "3", "tag3": "4"}, verb: "COMMAND"},
+	{input: "@tag1=1;tag2=3;tag3=4;tag1=5;vendor/tag2=8 COMMAND", tags: Tags{"tag1": "5", "tag2": "3", "tag3": "4", "vendor/tag2": "8"}, verb: "COMMAND"},
+	{input: ":SomeOp MODE #channel :+i", source: "SomeOp", verb: "MODE", params: []string{"#channel", "+i"}},
+	{input: ":SomeOp MODE #channel +oo SomeUser :AnotherUser", source: "SomeOp", verb: "MODE", params: []string{"#channel", "+oo", "SomeUser", "AnotherUser"}},
+	// not in the corpus: a middle parameter containing ":"
+	{input: ":srv 352 me #c ~u 2001:db8::1 srv nick H :0 Real", source: "srv", verb: "352", params: []string{"me", "#c", "~u", "2001:db8::1", "srv", "nick", "H", "0 Real"}},
+}
+
+// joinTests are taken from msg-join.yaml of the irc-parser-tests corpus
+var joinTests = []struct {
+	tags   Tags
+	source string
+	verb   string
+	params []string
+	want   string
+}{
+	{verb: "foo", params: []string{"bar", "baz", "asdf"}, want: "foo bar baz asdf"},
+	{source: "coolguy", verb: "foo", params: []string{"bar", "baz", "asdf"}, want: ":coolguy foo bar baz asdf"},
+	{verb: "foo", params: []string{"bar", "baz", "asdf quux"}, want: "foo bar baz :asdf quux"},
+	{verb: "foo", params: []string{"bar", "baz", ""}, want: "foo bar baz :"},
+	{verb: "foo", params: []string{"bar", "baz", ":asdf"}, want: "foo bar baz ::asdf"},
+	{source: "coolguy", verb: "foo", params: []string{"bar", "baz", "asdf quux"}, want: ":coolguy foo bar baz :asdf quux"},
+	{source: "coolguy", verb: "foo", params: []string{"bar", "baz", "  asdf quux "}, want: ":coolguy foo bar baz :  asdf quux "},
+	{source: "coolguy", verb: "PRIVMSG", params: []string{"bar", "lol :) "}, want: ":coolguy PRIVMSG bar :lol :) "},
+	{source: "coolguy", verb: "foo", params: []string{"bar", "baz", ""}, want: ":coolguy foo bar baz :"},
+	{source: "coolguy", verb: "foo", params: []string{"bar", "baz", "  "}, want: ":coolguy foo bar baz :  "},
+	{source: "coolguy", verb: "foo", params: []string{"b\tar", "baz"}, want: ":coolguy foo b\tar baz"},
+	{tags: Tags{"asd": ""}, source: "coolguy", verb: "foo", params: []string{"bar", "baz", "  "}, want: "@asd :coolguy foo bar baz :  "},
+	{tags: Tags{"a": "b\\and\nk"}, verb: "foo", want: "@a=b\\\\and\\nk foo"},
+	{tags: Tags{"d": "gh;764"}, verb: "foo", want: "@d=gh\\:764 foo"},
+	{verb: "COMMAND", want: "COMMAND"},
+}
+
+func TestParserConformance(t *testing.T) {
+	for _, test := range splitTests {
+		m, err := ParseMessage([]byte(test.input))
+		if err != nil {
+			t.Errorf("%q: %s", test.input, err)
+			continue
+		}
+		if m.Tags.String() != test.tags.String() {
+			t.Errorf("%q: tags got %q want %q", test.input, m.Tags, test.tags)
+		}
+		if m.Prefix.String() != test.source {
+			t.Errorf("%q: source got %q want %q", test.input, m.Prefix, test.source)
+		}
+		if m.Command != test.verb {
+			t.Errorf("%q: verb got %q want %q", test.input, m.Command, test.verb)
+		}
+		if ps := m.Params(); len(ps) != len(test.params) {
+			t.Errorf("%q: params got %q want %q", test.input, ps, test.params)
+		} else {
+			for i := range ps {
+				if ps[i] != test.params[i] {
+					t.Errorf("%q: params got %q want %q", test.input, ps, test.params)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestSerializeConformance(t *testing.T) {
+	for _, test := range joinTests {
+		m := Message{
+			Tags:    test.tags,
+			Prefix:  ParsePrefix(test.source),
+			Command: test.verb,
+			Parms:   test.params,
+		}
+		if got := m.String(); got != test.want+"\r\n" {
+			t.Errorf("got %q want %q", got, test.want+"\r\n")
+		}
+	}
+
+	empty := Message{Command: "PRIVMSG", Parms: Parms{"#c"}, HasTrailing: true}
+	if got := empty.String(); got != "PRIVMSG #c :\r\n" {
+		t.Errorf("empty trailing got %q", got)
+	}
+}
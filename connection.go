@@ -1,10 +1,14 @@
 package irc
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"io"
 	"log"
-	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,171 +30,410 @@ func (f HandlerFunc) ServeIRC(req Message, res chan<- Message) bool {
 	return f(req, res)
 }
 
+// Event is a notification of the Client besides Messages, e.g. a PresenceEvent
+type Event interface{}
+
+// Config holds the settings of a Client, zero values select the defaults
+type Config struct {
+	Nick string
+	User string // defaults to Nick
+
+	// SuppressEcho stops our own Messages echoed by the server (echo-message)
+	// from being passed to the Handler
+	SuppressEcho bool
+
+	// Fallback decodes received lines that are not valid UTF-8, defaults to CP1252
+	Fallback Charset
+	// Encoding encodes sent lines, nil sends UTF-8
+	Encoding Charset
+	// ChannelCharsets are used instead of Fallback and Encoding for the
+	// Messages of a channel, see SetChannelCharset
+	ChannelCharsets map[string]Charset
+
+	// SendQueue is the number of bulk Messages (PRIVMSG, NOTICE) queued per
+	// target before they get coalesced or dropped
+	SendQueue int
+
+	// PingInterval is the interval of our PINGs measuring the lag, defaults to
+	// DefaultPingInterval, a negative value disables them
+	PingInterval time.Duration
+	// PingTimeout is the time to wait for the PONG before the connection is
+	// considered dead and reconnected, defaults to DefaultPingTimeout
+	PingTimeout time.Duration
+
+	// Dialer connects to the server, defaults to DefaultDialer. An address
+	// "unix:/path/to/socket" is dialed as Unix socket.
+	Dialer Dialer
+
+	// Servers are tried in turn after the address of Dial when reconnecting
+	Servers []string
+	// Reconnect is the policy for reconnecting after the connection was lost
+	Reconnect ReconnectPolicy
+}
+
+// Defaults of Config
+const (
+	DefaultPingInterval = 30 * time.Second
+	DefaultPingTimeout  = time.Minute
+)
+
+// ErrClosed is returned by Send and Close after the Client was closed
+var ErrClosed = errors.New("client closed")
+
+var (
+	// sendInterval is the delay between two sent Messages keeping us below
+	// the flood limits of the server
+	sendInterval = 500 * time.Millisecond
+	// closeTimeout is the time Close waits for the QUIT to be sent and the
+	// ERROR reply of the server
+	closeTimeout = 5 * time.Second
+)
+
 // Client is a IRC connection
 type Client struct {
-	conn       net.Conn
+	conn       io.ReadWriteCloser
+	connCancel context.CancelFunc // stops the sendLoop of conn
+	connDone   chan struct{}      // closed when the sendLoop of conn returned
 	address    string
+	servers    []string // address and Config.Servers
+	server     int      // index of address in servers
 	nick, user string
+	cfg        Config
+
+	mu             sync.Mutex
+	handler        Handler
+	recvGoroutine  uint64            // id of the recvLoop goroutine running the Handler
+	eventsClosed   bool
+	prefix         Prefix            // our own prefix as seen by the server
+	caps           map[string]string // capabilities offered by the server
+	capsEnabled    map[string]bool
+	capNegotiating bool
+	isupport       ISupport
+	charsets       map[string]Charset // by lower case channel
+	batchID        int
+	labelID        int
+	labels         map[string]chan []Message // SendAndWait waiting for a label
+	pingToken      string                    // token of the PING waiting for a PONG
+	pingSent       time.Time
+	lag            time.Duration
+
+	batches map[string]Message // open BATCHes by reference
+
+	ctx       context.Context // canceled by Close
+	cancel    context.CancelFunc
+	quitting  chan struct{} // closed by Close before sending the QUIT
+	closeOnce sync.Once
 
-	Msg        chan Message
-	send       chan Message
-	Done       chan struct{}
-	sendDone   chan struct{}
-	resHandler chan Handler
+	Msg    chan Message
+	Events chan Event   // Events get dropped if it is full, closed with Done
+	send   chan Message // read by the sendLoop of the current connection
+	// Done is closed after all goroutines of the Client returned, after Close
+	// or when reconnecting failed
+	Done chan struct{}
 }
 
 // Dial connects to address witch nick and user name
 func Dial(address, nick, user string) (*Client, error) {
-	var c = &Client{
-		address:    address,
-		nick:       nick,
-		user:       user,
-		resHandler: make(chan Handler, 1),
-		Done:       make(chan struct{}),
-		sendDone:   make(chan struct{}),
-	}
+	return DialConfig(address, Config{Nick: nick, User: user})
+}
+
+// DialConfig connects to address with the settings in cfg and returns after
+// the registration
+func DialConfig(address string, cfg Config) (*Client, error) {
+	c := newClient(cfg)
+	c.address = address
+	c.servers = append([]string{address}, cfg.Servers...)
 	if err := c.connect(); err != nil {
+		c.cancel()
 		return nil, err
 	}
+	return c.register()
+}
+
+// NewClient registers on conn with the settings in cfg and returns after the
+// registration. The Client does not reconnect when conn fails, it closes.
+func NewClient(conn io.ReadWriteCloser, cfg Config) (*Client, error) {
+	c := newClient(cfg)
+	c.start(conn)
+	return c.register()
+}
+
+// newClient returns a Client without connection, the zero values of cfg are
+// replaced with the defaults
+func newClient(cfg Config) *Client {
+	if cfg.User == "" {
+		cfg.User = cfg.Nick
+	}
+	if cfg.Fallback == nil {
+		cfg.Fallback = CP1252
+	}
+	if cfg.PingInterval == 0 {
+		cfg.PingInterval = DefaultPingInterval
+	}
+	if cfg.PingTimeout <= 0 {
+		cfg.PingTimeout = DefaultPingTimeout
+	}
+	cfg.Reconnect = cfg.Reconnect.withDefaults()
+	var c = &Client{
+		nick:     cfg.Nick,
+		user:     cfg.User,
+		cfg:      cfg,
+		handler:  defaultHandler,
+		labels:   make(map[string]chan []Message),
+		charsets: make(map[string]Charset),
+		quitting: make(chan struct{}),
+		Msg:      make(chan Message, 10),
+		Events:   make(chan Event, 64),
+		send:     make(chan Message, 10),
+		Done:     make(chan struct{}),
+	}
+	for channel, cs := range cfg.ChannelCharsets {
+		if cs != nil {
+			c.charsets[strings.ToLower(channel)] = cs
+		}
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	return c
+}
+
+// register starts recvLoop and waits for the end of the MOTD, a rejected
+// registration returns the *ServerError of the server
+func (c *Client) register() (*Client, error) {
 	c.recvLoop()
 	for m := range c.Msg {
-		if m.Command == ircRplENDOFMOTD {
+		switch m.Command {
+		case RplENDOFMOTD, ErrNOMOTD:
 			log.Print(m)
-			break
+			return c, nil
+		case ErrNONICKNAMEGIVEN, ErrERRONEUSNICKNAME, ErrNICKNAMEINUSE, ErrNICKCOLLISION,
+			ErrUNAVAILRESOURCE, ErrPASSWDMISMATCH, ErrYOUREBANNEDCREEP:
+			c.cancel()
+			<-c.Done
+			return nil, m.Err()
+		case "ERROR":
+			c.cancel()
+			<-c.Done
+			return nil, errors.New("registration failed: " + lastParm(m))
 		}
 	}
-	return c, nil
+	c.cancel()
+	return nil, errors.New("connection closed during registration")
+}
 
+// Close sends a QUIT with reason, waits for the ERROR reply of the server,
+// closes the connection and returns after all goroutines of the Client
+// returned. Called from a Handler it returns after sending the QUIT and the
+// Client closes once the Handler returned. Further calls return ErrClosed.
+func (c *Client) Close(reason string) error {
+	err := ErrClosed
+	c.closeOnce.Do(func() {
+		err = nil
+		close(c.quitting)
+		log.Print("send QUIT message")
+		deadline := time.Now().Add(closeTimeout)
+		select {
+		case c.send <- Quit(reason):
+		case <-c.Done:
+		case <-time.After(closeTimeout):
+		}
+
+		c.mu.Lock()
+		inHandler := c.recvGoroutine == goroutineID()
+		c.mu.Unlock()
+		if inHandler {
+			// recvLoop waits for the Handler calling us
+			go c.shutdown(deadline)
+			return
+		}
+		c.shutdown(deadline)
+	})
+	return err
 }
 
-// Close disconnect from server
-func (c *Client) Close() {
-	c.Quit()
-	if _, open := <-c.Done; open {
-		close(c.Done)
+// shutdown waits for the ERROR reply to the QUIT till deadline and stops the
+// Client
+func (c *Client) shutdown(deadline time.Time) {
+	select {
+	case <-c.Done:
+	case <-time.After(time.Until(deadline)):
+		log.Print("Close: no ERROR reply to QUIT")
+	}
+	c.cancel()
+	// Handlers may still send till recvLoop returned
+	for {
+		select {
+		case <-c.send:
+		case <-c.Done:
+			return
+		}
+	}
+}
+
+// closing reports if Close was called
+func (c *Client) closing() bool {
+	select {
+	case <-c.quitting:
+		return true
+	default:
+		return false
 	}
 }
 
 // Handle sets respons Handler
-func (c Client) Handle(h Handler) {
-	c.resHandler <- h
+func (c *Client) Handle(h Handler) {
+	c.mu.Lock()
+	c.handler = h
+	c.mu.Unlock()
 }
 
 // HandleFunc sets respons Handler
-func (c Client) HandleFunc(f func(Message, chan<- Message) bool) {
+func (c *Client) HandleFunc(f func(Message, chan<- Message) bool) {
 	c.Handle(HandlerFunc(f))
 }
 
-// Send sends Message to the connectet server
+// Send sends Message to the connectet server. The Message gets a label tag if
+// the labeled-response capability is enabled. Invalid Messages are rejected
+// with a *ValidationError, after Close or once Done is closed it returns
+// ErrClosed.
 func (c *Client) Send(m Message) error {
-	c.send <- m
-	return nil
+	return c.enqueue(c.label(m))
 }
 
-/*
-func (c *Client) SendChan() chan<- Message {
-	return c.send
-}
-*/
-
-// Quit disconnects from server
-func (c *Client) Quit() {
-	if c.send != nil {
-		log.Print("send QUIT message")
-		c.send <- Message{
-			Command:  "QUIT",
-			Trailing: "watch this!",
-		}
+// enqueue validates m and passes it to the sendLoop without adding a label
+func (c *Client) enqueue(m Message) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if c.closing() || c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	select {
+	case c.send <- m:
+		return nil
+	case <-c.ctx.Done():
+		return ErrClosed
 	}
 }
 
 func (c *Client) connect() error {
 	log.Print("connecting to ", c.address)
-	var err error
-	if c.conn, err = net.Dial("tcp4", c.address); err != nil {
+	network, addr := splitNetwork(c.address)
+	conn, err := forward(c.cfg.Dialer).DialContext(c.ctx, network, addr)
+	if err != nil {
 		return err
 	}
+	c.start(conn)
+	return nil
+}
 
-	c.sendLoop()
-	c.send <- Message{
-		Command:  "USER",
-		Parms:    Parms{c.user, "0", "*"},
-		Trailing: c.user,
-	}
-	c.send <- Message{
-		Command: "NICK",
-		Parms:   Parms{c.nick},
-	}
+// start starts the sendLoop for conn and the registration
+func (c *Client) start(conn io.ReadWriteCloser) {
+	c.conn = conn
+	c.batches = make(map[string]Message)
+	c.mu.Lock()
+	c.isupport = make(ISupport)
+	c.pingToken = ""
+	c.mu.Unlock()
 
-	return nil
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.connCancel, c.connDone = cancel, make(chan struct{})
+	c.sendLoop(ctx, conn, c.connDone)
+	c.capStart()
+	c.send <- Register(c.user, c.user)
+	c.send <- Nick(c.nick)
 }
 
-func (c *Client) reconnect() error {
-	if c.conn != nil {
-		if err := c.conn.Close(); err != nil {
-			return err
-		}
-	}
-	for err := c.connect(); err != nil; err = c.connect() {
-		log.Println(err)
-	}
-	return nil
+// stopConn stops the sendLoop and closes the connection
+func (c *Client) stopConn() {
+	c.connCancel()
+	<-c.connDone
+	c.conn.Close()
 }
 
 func (c *Client) recvLoop() {
 	log.Print("recvLoop start")
-	c.Msg = make(chan Message, 10)
 
 	go func() {
+		c.mu.Lock()
+		c.recvGoroutine = goroutineID()
+		c.mu.Unlock()
 		defer func() {
-			if c.send != nil {
-				close(c.send)
-				<-c.sendDone
-			}
-			if c.conn != nil {
-				c.conn.Close()
-			}
+			// Send and SendAndWait fail from now on
+			c.cancel()
+			c.stopConn()
 			close(c.Msg)
+			c.mu.Lock()
+			c.eventsClosed = true
+			close(c.Events)
+			c.mu.Unlock()
 			log.Print("recvLoop close")
-			c.Done <- struct{}{}
+			close(c.Done)
 		}()
 
-		resHandler := Handler(defaultHandler)
-		buf := bufio.NewReader(c.conn)
+		dec := NewDecoder(c.conn)
 		for {
-			c.conn.SetDeadline(time.Now().Add(300 * time.Second))
-			b, _, err := buf.ReadLine()
+			if d, ok := c.conn.(interface{ SetDeadline(time.Time) error }); ok {
+				d.SetDeadline(time.Now().Add(c.readTimeout()))
+			}
+			m, err := dec.Decode()
 			switch err {
 			case nil:
-			case io.EOF:
-				return
+			case ErrLineTooLong, ErrNoCommand:
+				log.Printf("recvLoop: %s\nraw: %#v", err, m.Raw)
+				continue
 			default:
+				if c.closing() {
+					return
+				}
 				log.Print(err)
-				close(c.send)
-				<-c.sendDone
-				if err := c.reconnect(); err != nil {
+				if err := c.reconnect(err); err != nil {
 					log.Print(err)
 					return
 				}
+				dec = NewDecoder(c.conn)
 				continue
+			}
 
+			c.decode(&m)
+			if m.Time.IsZero() {
+				m.Time = time.Now()
 			}
+			m.Self = c.isEcho(m)
 
-			m, err := ParseMessage(b)
-			if err != nil {
-				log.Printf("recvLoop: %s\nraw: %#v", err, b)
+			c.track(m)
+			var ok bool
+			if m, ok = c.collectBatch(m); !ok {
 				continue
 			}
-
-			select {
-			case resHandler = <-c.resHandler:
-			default:
+			// responses to SendAndWait are passed on too, except an ACK and
+			// our echo
+			delivered := c.deliverLabeled(m)
+			if delivered && (m.Self || m.Command == "ACK") {
+				continue
 			}
-
-			if m.Command == "PING" {
-				c.send <- Message{Command: "PONG", Trailing: m.Trailing}
-			} else if !resHandler.ServeIRC(m, c.send) {
-				c.Msg <- m
+			if m.Self && c.cfg.SuppressEcho {
+				continue
+			}
+			if r, ok := ParseStandardReply(m); ok && !delivered {
+				c.emit(StandardReplyEvent{*r, m})
+			}
+			switch m.Command {
+			case "PING":
+				c.send <- Message{Command: "PONG", Trailing: lastParm(m), HasTrailing: true}
+			case "PONG":
+				if !c.pong(m) {
+					c.serve(m)
+				}
+			case "CAP":
+				c.handleCap(m)
+			case "ERROR":
+				if c.closing() {
+					// the server confirmed our QUIT
+					return
+				}
+				c.serve(m)
+			default:
+				c.serve(m)
 			}
 		}
 	}()
@@ -198,24 +441,225 @@ func (c *Client) recvLoop() {
 	return
 }
 
-func (c *Client) sendLoop() {
+// goroutineID returns the id of the calling goroutine from the header of its
+// stack trace "goroutine 18 [running]:"
+func goroutineID() uint64 {
+	var buf [64]byte
+	s := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseUint(s, 10, 64)
+	return id
+}
+
+// serve passes m to the Handler and to Msg if the Handler did not handle it
+func (c *Client) serve(m Message) {
+	c.mu.Lock()
+	h := c.handler
+	c.mu.Unlock()
+	if h.ServeIRC(m, c.send) {
+		return
+	}
+	select {
+	case c.Msg <- m:
+	case <-c.ctx.Done():
+	}
+}
+
+// emit passes e to Events without blocking
+func (c *Client) emit(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.eventsClosed {
+		return
+	}
+	select {
+	case c.Events <- e:
+	default:
+		log.Printf("Events full, dropped %#v", e)
+	}
+}
+
+// isEcho reports if m is one of our Messages echoed by the server, without
+// echo-message it is a Message from another client using our nick (e.g. the
+// playback of a bouncer) and not an echo
+func (c *Client) isEcho(m Message) bool {
+	switch m.Command {
+	case "PRIVMSG", "NOTICE", "TAGMSG":
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.capsEnabled["echo-message"] && strings.EqualFold(m.Prefix.Nick, c.nick)
+	}
+	return false
+}
+
+// track updates the state of the Client from m
+func (c *Client) track(m Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch m.Command {
+	case RplISUPPORT:
+		if len(m.Parms) > 1 {
+			c.isupport.update(m.Parms[1:])
+		}
+		return
+	case RplWELCOME:
+		// the server may have changed our nick e.g. by truncating it
+		if len(m.Parms) > 0 {
+			c.nick = m.Parms[0]
+			c.prefix.Nick = c.nick
+		}
+		return
+	case RplVISIBLEHOST:
+		if len(m.Parms) > 1 {
+			c.prefix.Host = m.Parms[1]
+		}
+		return
+	}
+	if m.Prefix.Nick != c.nick {
+		return
+	}
+	switch m.Command {
+	case "JOIN":
+		c.prefix = m.Prefix
+	case "NICK":
+		nick := m.Trailing
+		if len(m.Parms) > 0 {
+			nick = m.Parms[0]
+		}
+		c.nick = nick
+		c.prefix = m.Prefix
+		c.prefix.Nick = nick
+	case "CHGHOST":
+		if len(m.Parms) > 0 {
+			c.prefix = Prefix{Nick: c.nick, User: m.Parms[0], Host: lastParm(m)}
+		}
+	}
+}
+
+// sendLoop writes the Messages of send to conn till ctx is done, it closes
+// conn when it returns
+func (c *Client) sendLoop(ctx context.Context, conn io.WriteCloser, done chan<- struct{}) {
 	log.Print("sendLoop start")
-	c.send = make(chan Message, 10)
 	go func() {
 		defer func() {
-			c.send = nil
-			c.sendDone <- struct{}{}
+			conn.Close()
+			close(done)
 			log.Print("sendLoop close")
 		}()
-		ticker := time.Tick(500 * time.Millisecond)
-		for m := range c.send {
-			<-ticker
-			if _, err := c.conn.Write([]byte(m.String())); err != nil {
-				log.Print("sendLoop: ", err)
+		q := newSendQueue(c.cfg.SendQueue, c.textBudget)
+		enc := NewEncoder(conn)
+		ticker := time.NewTicker(sendInterval)
+		defer ticker.Stop()
+		var ping <-chan time.Time
+		if c.cfg.PingInterval > 0 {
+			pinger := time.NewTicker(c.cfg.PingInterval)
+			defer pinger.Stop()
+			ping = pinger.C
+		}
+		var pingTimeout <-chan time.Time
+		// after a failed write recvLoop fails reading and reconnects, till
+		// then Messages are dropped so that nobody blocks on send
+		failed := false
+		for {
+			var tick <-chan time.Time
+			if q.Len() > 0 && !failed {
+				tick = ticker.C
+			}
+			select {
+			case <-ctx.Done():
 				return
+			case <-ping:
+				m, ok := c.ping()
+				if !ok || failed {
+					continue
+				}
+				enc.Charset = nil
+				if err := enc.Encode(m); err != nil {
+					log.Print("sendLoop: ", err)
+					conn.Close()
+					failed = true
+					continue
+				}
+				pingTimeout = time.After(c.cfg.PingTimeout)
+			case <-pingTimeout:
+				pingTimeout = nil
+				if c.pingTimedOut() {
+					log.Print("sendLoop: ping timeout")
+					conn.Close()
+					failed = true
+				}
+			case m := <-c.send:
+				if failed {
+					log.Printf("sendLoop: dropped %q", m.String())
+					continue
+				}
+				// Messages of Handlers did not pass Send
+				if err := m.Validate(); err != nil {
+					log.Print("sendLoop: ", err)
+					continue
+				}
+				q.Push(m)
+			case <-tick:
+				m, _ := q.Pop()
+				enc.Charset = c.charset(m, c.cfg.Encoding)
+				if err := enc.Encode(m); err != nil {
+					log.Print("sendLoop: ", err)
+					conn.Close()
+					failed = true
+				}
 			}
 		}
 	}()
+}
 
-	return
+// readTimeout returns the deadline for reads, PINGs keep the connection busy
+func (c *Client) readTimeout() time.Duration {
+	if c.cfg.PingInterval > 0 {
+		return c.cfg.PingInterval + c.cfg.PingTimeout
+	}
+	return 300 * time.Second
+}
+
+// ping returns a new PING if no other PING is waiting for its PONG
+func (c *Client) ping() (Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pingToken != "" {
+		return Message{}, false
+	}
+	c.pingSent = time.Now()
+	c.pingToken = "lag" + strconv.FormatInt(c.pingSent.UnixNano(), 36)
+	return Ping(c.pingToken), true
+}
+
+// pingTimedOut reports if the last PING is still waiting for its PONG
+func (c *Client) pingTimedOut() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pingToken == "" {
+		return false
+	}
+	c.pingToken = ""
+	return true
+}
+
+// pong updates the lag if m answers our PING and reports if it did
+func (c *Client) pong(m Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pingToken == "" || lastParm(m) != c.pingToken {
+		return false
+	}
+	c.lag = time.Since(c.pingSent)
+	c.pingToken = ""
+	return true
+}
+
+// Lag returns the round-trip time of the last answered PING
+func (c *Client) Lag() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lag
 }
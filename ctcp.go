@@ -0,0 +1,173 @@
+package irc
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCTCPVersion is the default reply to a CTCP VERSION query
+	DefaultCTCPVersion = "github.com/juggle-tux/irc"
+	// DefaultCTCPSource is the default reply to a CTCP SOURCE query
+	DefaultCTCPSource = "https://github.com/juggle-tux/irc"
+	// DefaultCTCPInterval is the default minimal time between two CTCP replies
+	DefaultCTCPInterval = time.Second
+
+	ctcpDelim = "\x01"
+)
+
+var (
+	lowQuoter   = strings.NewReplacer("\x10", "\x10\x10", "\x00", "\x100", "\n", "\x10n", "\r", "\x10r")
+	lowDequoter = strings.NewReplacer("\x10\x10", "\x10", "\x100", "\x00", "\x10n", "\n", "\x10r", "\r", "\x10", "")
+)
+
+// LowQuote applies the CTCP low-level quoting to s, it escapes NUL, CR, LF
+// and the quote character \x10
+func LowQuote(s string) string {
+	return lowQuoter.Replace(s)
+}
+
+// LowDequote reverts LowQuote
+func LowDequote(s string) string {
+	return lowDequoter.Replace(s)
+}
+
+// CTCP is a Client-To-Client Protocol query (in a PRIVMSG) or reply (in a
+// NOTICE) like "\x01VERSION\x01" or "\x01ACTION waves\x01"
+type CTCP struct {
+	Command string
+	Params  string
+}
+
+// String returns the quoted CTCP as used in the Trailing of a Message
+func (c CTCP) String() string {
+	if c.Params == "" {
+		return ctcpDelim + LowQuote(c.Command) + ctcpDelim
+	}
+	return ctcpDelim + LowQuote(c.Command+" "+c.Params) + ctcpDelim
+}
+
+// ParseCTCP returns the CTCP in a PRIVMSG or NOTICE, ok is false if m
+// contains none
+func ParseCTCP(m Message) (c CTCP, ok bool) {
+	if (m.Command != "PRIVMSG" && m.Command != "NOTICE") || !strings.HasPrefix(m.Trailing, ctcpDelim) {
+		return c, false
+	}
+	// the closing delimiter is optional
+	str := strings.TrimSuffix(m.Trailing[len(ctcpDelim):], ctcpDelim)
+	str = LowDequote(str)
+	i := strings.Index(str, " ")
+	if i < 0 {
+		c.Command = str
+	} else {
+		c.Command, c.Params = str[:i], str[i+len(" "):]
+	}
+	c.Command = strings.ToUpper(c.Command)
+	return c, c.Command != ""
+}
+
+// CTCPQuery creates a PRIVMSG to target containing the CTCP query command
+func CTCPQuery(target, command, params string) Message {
+	return Message{
+		Command:  "PRIVMSG",
+		Parms:    Parms{0: target},
+		Trailing: CTCP{Command: command, Params: params}.String(),
+	}
+}
+
+// CTCPReply creates a NOTICE to target containing the CTCP reply to command
+func CTCPReply(target, command, params string) Message {
+	return Message{
+		Command:  "NOTICE",
+		Parms:    Parms{0: target},
+		Trailing: CTCP{Command: command, Params: params}.String(),
+	}
+}
+
+// Action creates a CTCP ACTION ("/me") to target (channel/nick)
+func Action(target, text string) Message {
+	return CTCPQuery(target, "ACTION", text)
+}
+
+// CTCPEvent is emitted on Client.Events for every received CTCP
+type CTCPEvent struct {
+	CTCP
+	Message Message
+	Reply   bool // a reply in a NOTICE
+}
+
+// CTCPResponder answers the CTCP queries VERSION, PING, TIME, CLIENTINFO and
+// SOURCE and emits a CTCPEvent for every CTCP. ACTIONs are passed to the
+// DefaultHandler, the other CTCPs are consumed.
+type CTCPResponder struct {
+	Version string
+	Source  string
+	// Interval is the minimal time between two replies, queries exceeding
+	// it are not answered
+	Interval       time.Duration
+	DefaultHandler Handler
+
+	cl   *Client
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewCTCPResponder returns a new CTCPResponder for Client
+func NewCTCPResponder(cl *Client) *CTCPResponder {
+	return &CTCPResponder{
+		Version:        DefaultCTCPVersion,
+		Source:         DefaultCTCPSource,
+		Interval:       DefaultCTCPInterval,
+		DefaultHandler: defaultHandler,
+		cl:             cl,
+	}
+}
+
+// allow reports if a reply may be send now
+func (r *CTCPResponder) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if now.Sub(r.last) < r.Interval {
+		return false
+	}
+	r.last = now
+	return true
+}
+
+// reply returns the reply params to the query q, ok is false for unknown queries
+func (r *CTCPResponder) reply(q CTCP) (params string, ok bool) {
+	switch q.Command {
+	case "VERSION":
+		return r.Version, true
+	case "PING":
+		return q.Params, true
+	case "TIME":
+		return time.Now().Format(time.RFC1123Z), true
+	case "CLIENTINFO":
+		return "ACTION CLIENTINFO PING SOURCE TIME VERSION", true
+	case "SOURCE":
+		return r.Source, true
+	}
+	return "", false
+}
+
+// ServeIRC implaments Handler
+func (r *CTCPResponder) ServeIRC(req Message, res chan<- Message) bool {
+	q, ok := ParseCTCP(req)
+	if !ok {
+		return r.DefaultHandler.ServeIRC(req, res)
+	}
+	r.cl.emit(CTCPEvent{CTCP: q, Message: req, Reply: req.Command == "NOTICE"})
+
+	if q.Command == "ACTION" {
+		return r.DefaultHandler.ServeIRC(req, res)
+	}
+	if req.Command == "PRIVMSG" && !req.Self && req.Prefix.Nick != "" {
+		if params, ok := r.reply(q); ok && r.allow() {
+			res <- CTCPReply(req.Prefix.Nick, q.Command, params)
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package irc
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialer connects to the server, *net.Dialer implements it
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DefaultDialer is used if Config.Dialer is nil. It dials IPv6 and IPv4
+// addresses in parallel (happy eyeballs) with a head start for the first.
+var DefaultDialer Dialer = &net.Dialer{
+	Timeout:       30 * time.Second,
+	FallbackDelay: 300 * time.Millisecond,
+}
+
+// splitNetwork returns the network and address of a server address,
+// "unix:/path/to/socket" selects a Unix socket and everything else TCP
+func splitNetwork(address string) (network, addr string) {
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		return "unix", path
+	}
+	return "tcp", address
+}
+
+func forward(d Dialer) Dialer {
+	if d == nil {
+		return DefaultDialer
+	}
+	return d
+}
+
+// closeOnCancel closes conn if ctx is done before the returned stop is called
+func closeOnCancel(ctx context.Context, conn net.Conn) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// SOCKS5Dialer connects through the SOCKS5 proxy at Address (RFC 1928) and
+// authenticates with Username and Password if set (RFC 1929). The server
+// address is resolved by the proxy.
+type SOCKS5Dialer struct {
+	Address            string
+	Username, Password string
+	Forward            Dialer // connects to the proxy, defaults to DefaultDialer
+}
+
+// socks5Errors are the reply codes of a failed CONNECT
+var socks5Errors = []string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// DialContext implements Dialer, network must be "tcp"
+func (d *SOCKS5Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" {
+		return nil, errors.New("socks5: network " + network + " not supported")
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, errors.New("socks5: invalid port " + portStr)
+	}
+	if len(host) > 255 {
+		return nil, errors.New("socks5: host name too long")
+	}
+
+	conn, err := forward(d.Forward).DialContext(ctx, "tcp", d.Address)
+	if err != nil {
+		return nil, err
+	}
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
+	if err := d.connect(conn, host, uint16(port)); err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (d *SOCKS5Dialer) connect(conn net.Conn, host string, port uint16) error {
+	buf := make([]byte, 0, 6+len(host))
+	// greeting with the supported methods
+	if d.Username != "" {
+		buf = append(buf, 5, 2, 0, 2)
+	} else {
+		buf = append(buf, 5, 1, 0)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 5 {
+		return errors.New("socks5: unexpected version " + strconv.Itoa(int(reply[0])))
+	}
+	switch reply[1] {
+	case 0:
+	case 2:
+		if d.Username == "" {
+			return errors.New("socks5: proxy requires authentication")
+		}
+		if len(d.Username) > 255 || len(d.Password) > 255 {
+			return errors.New("socks5: username or password too long")
+		}
+		buf = append(buf[:0], 1, byte(len(d.Username)))
+		buf = append(buf, d.Username...)
+		buf = append(buf, byte(len(d.Password)))
+		buf = append(buf, d.Password...)
+		if _, err := conn.Write(buf); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0 {
+			return errors.New("socks5: authentication failed")
+		}
+	default:
+		return errors.New("socks5: no acceptable authentication method")
+	}
+
+	// CONNECT with the host name or IP
+	buf = append(buf[:0], 5, 1, 0)
+	if ip := net.ParseIP(host); ip == nil {
+		buf = append(buf, 3, byte(len(host)))
+		buf = append(buf, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		buf = append(buf, 1)
+		buf = append(buf, ip4...)
+	} else {
+		buf = append(buf, 4)
+		buf = append(buf, ip...)
+	}
+	buf = append(buf, byte(port>>8), byte(port))
+	if _, err := conn.Write(buf); err != nil {
+		return err
+	}
+
+	// reply: version, code, reserved, bound address
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	if code := int(head[1]); code != 0 {
+		if code < len(socks5Errors) {
+			return errors.New("socks5: " + socks5Errors[code])
+		}
+		return errors.New("socks5: CONNECT failed with code " + strconv.Itoa(code))
+	}
+	var n int
+	switch head[3] {
+	case 1:
+		n = net.IPv4len
+	case 4:
+		n = net.IPv6len
+	case 3:
+		if _, err := io.ReadFull(conn, head[:1]); err != nil {
+			return err
+		}
+		n = int(head[0])
+	default:
+		return errors.New("socks5: unknown address type " + strconv.Itoa(int(head[3])))
+	}
+	_, err := io.ReadFull(conn, make([]byte, n+2))
+	return err
+}
+
+// HTTPConnectDialer connects through the HTTP proxy at Address with the
+// CONNECT method and authenticates with Username and Password if set
+type HTTPConnectDialer struct {
+	Address            string
+	Username, Password string
+	Forward            Dialer // connects to the proxy, defaults to DefaultDialer
+}
+
+// DialContext implements Dialer, network must be "tcp"
+func (d *HTTPConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" {
+		return nil, errors.New("http connect: network " + network + " not supported")
+	}
+	conn, err := forward(d.Forward).DialContext(ctx, "tcp", d.Address)
+	if err != nil {
+		return nil, err
+	}
+	stop := closeOnCancel(ctx, conn)
+	defer stop()
+
+	req := "CONNECT " + address + " HTTP/1.1\r\nHost: " + address + "\r\n"
+	if d.Username != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(d.Username + ":" + d.Password))
+		req += "Proxy-Authorization: Basic " + auth + "\r\n"
+	}
+	req += "\r\n"
+	fail := func(err error) (net.Conn, error) {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if _, err := io.WriteString(conn, req); err != nil {
+		return fail(err)
+	}
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, &http.Request{Method: "CONNECT"})
+	if err != nil {
+		return fail(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fail(errors.New("http connect: proxy responded " + res.Status))
+	}
+	if r.Buffered() > 0 {
+		// the server already send something
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads the bytes buffered by r before reading from Conn
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
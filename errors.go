@@ -0,0 +1,117 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ServerError is an error reply of the server (numerics 400 to 599 and the
+// SASL errors). Use errors.Is with the sentinels below to check the Code.
+type ServerError struct {
+	Code   string // e.g. "433"
+	Name   string // e.g. "ERR_NICKNAMEINUSE"
+	Target string // the parameters between our nick and Text, e.g. the channel
+	Text   string
+}
+
+func (e *ServerError) Error() string {
+	str := e.Name
+	if str == "" {
+		str = e.Code
+	}
+	if e.Target != "" {
+		str += " " + e.Target
+	}
+	if e.Text != "" {
+		str += ": " + e.Text
+	}
+	return str
+}
+
+// Is reports if target is a *ServerError with the same Code
+func (e *ServerError) Is(target error) bool {
+	t, ok := target.(*ServerError)
+	return ok && t.Code == e.Code
+}
+
+func newServerError(code string) *ServerError {
+	n, _ := LookupNumeric(code)
+	return &ServerError{Code: code, Name: n.Name}
+}
+
+// Sentinel ServerErrors for errors.Is
+var (
+	ErrNoSuchNick        = newServerError(ErrNOSUCHNICK)
+	ErrNoSuchChannel     = newServerError(ErrNOSUCHCHANNEL)
+	ErrCannotSendToChan  = newServerError(ErrCANNOTSENDTOCHAN)
+	ErrTooManyChannels   = newServerError(ErrTOOMANYCHANNELS)
+	ErrTooManyTargets    = newServerError(ErrTOOMANYTARGETS)
+	ErrUnknownCommand    = newServerError(ErrUNKNOWNCOMMAND)
+	ErrErroneousNickname = newServerError(ErrERRONEUSNICKNAME)
+	ErrNickInUse         = newServerError(ErrNICKNAMEINUSE)
+	ErrUnavailResource   = newServerError(ErrUNAVAILRESOURCE)
+	ErrUserNotInChannel  = newServerError(ErrUSERNOTINCHANNEL)
+	ErrNotOnChannel      = newServerError(ErrNOTONCHANNEL)
+	ErrUserOnChannel     = newServerError(ErrUSERONCHANNEL)
+	ErrNotRegistered     = newServerError(ErrNOTREGISTERED)
+	ErrNeedMoreParams    = newServerError(ErrNEEDMOREPARAMS)
+	ErrAlreadyRegistered = newServerError(ErrALREADYREGISTRED)
+	ErrPasswdMismatch    = newServerError(ErrPASSWDMISMATCH)
+	ErrYoureBannedCreep  = newServerError(ErrYOUREBANNEDCREEP)
+	ErrChannelIsFull     = newServerError(ErrCHANNELISFULL)
+	ErrUnknownMode       = newServerError(ErrUNKNOWNMODE)
+	ErrInviteOnlyChan    = newServerError(ErrINVITEONLYCHAN)
+	ErrBannedFromChan    = newServerError(ErrBANNEDFROMCHAN)
+	ErrBadChannelKey     = newServerError(ErrBADCHANNELKEY)
+	ErrNoPrivileges      = newServerError(ErrNOPRIVILEGES)
+	ErrChanOPrivsNeeded  = newServerError(ErrCHANOPRIVSNEEDED)
+	ErrUsersDontMatch    = newServerError(ErrUSERSDONTMATCH)
+	ErrMonListFull       = newServerError(ErrMONLISTFULL)
+	ErrSASLFail          = newServerError(ErrSASLFAIL)
+	ErrSASLAborted       = newServerError(ErrSASLABORTED)
+	ErrUModeUnknownFlag  = newServerError(ErrUMODEUNKNOWNFLAG)
+	ErrNoOperHost        = newServerError(ErrNOOPERHOST)
+	ErrNoNicknameGiven   = newServerError(ErrNONICKNAMEGIVEN)
+	ErrNickCollision     = newServerError(ErrNICKCOLLISION)
+	ErrKeySet            = newServerError(ErrKEYSET)
+	ErrBanListFull       = newServerError(ErrBANLISTFULL)
+	ErrNoTextToSend      = newServerError(ErrNOTEXTTOSEND)
+	ErrNoRecipient       = newServerError(ErrNORECIPIENT)
+	ErrNoSuchServer      = newServerError(ErrNOSUCHSERVER)
+	ErrNoMOTD            = newServerError(ErrNOMOTD)
+	ErrBadChanMask       = newServerError(ErrBADCHANMASK)
+	ErrNickLocked        = newServerError(ErrNICKLOCKED)
+	ErrSASLTooLong       = newServerError(ErrSASLTOOLONG)
+	ErrSASLAlready       = newServerError(ErrSASLALREADY)
+)
+
+// isErrorNumeric reports if code is an error reply
+func isErrorNumeric(code string) bool {
+	if n, ok := LookupNumeric(code); ok {
+		return strings.HasPrefix(n.Name, "ERR_")
+	}
+	i, err := strconv.Atoi(code)
+	return err == nil && len(code) == 3 && i >= 400 && i < 600
+}
+
+// Err returns the *ServerError of an error reply, the *StandardReply of a FAIL
+// or nil for any other Message
+func (m Message) Err() error {
+	if r, ok := ParseStandardReply(m); ok && r.Type == "FAIL" {
+		return r
+	}
+	if !isErrorNumeric(m.Command) {
+		return nil
+	}
+	e := newServerError(m.Command)
+	ps := m.Params()
+	if len(ps) > 0 {
+		// the first parameter is our nick
+		ps = ps[1:]
+	}
+	if len(ps) > 0 {
+		e.Target = strings.Join(ps[:len(ps)-1], " ")
+		e.Text = ps[len(ps)-1]
+	}
+	return e
+}
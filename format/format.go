@@ -0,0 +1,269 @@
+// Package format parses the mIRC formatting codes used in IRC messages and
+// strips them or renders them to ANSI terminal escapes and HTML.
+package format
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Formatting codes
+const (
+	Bold          = '\x02'
+	ColorCode     = '\x03'
+	HexColorCode  = '\x04'
+	Reset         = '\x0f'
+	Monospace     = '\x11'
+	Reverse       = '\x16'
+	Italic        = '\x1d'
+	Strikethrough = '\x1e'
+	Underline     = '\x1f'
+)
+
+// palette holds the RGB values of the mIRC color codes 0-98
+var palette = [99]uint32{
+	0xffffff, 0x000000, 0x00007f, 0x009300, 0xff0000, 0x7f0000, 0x9c009c, 0xfc7f00,
+	0xffff00, 0x00fc00, 0x009393, 0x00ffff, 0x0000fc, 0xff00ff, 0x7f7f7f, 0xd2d2d2,
+	0x470000, 0x472100, 0x474700, 0x324700, 0x004700, 0x00472c, 0x004747, 0x002747, 0x000047, 0x2e0047, 0x470047, 0x47002a,
+	0x740000, 0x743a00, 0x747400, 0x517400, 0x007400, 0x007449, 0x007474, 0x004074, 0x000074, 0x4b0074, 0x740074, 0x740045,
+	0xb50000, 0xb56300, 0xb5b500, 0x7db500, 0x00b500, 0x00b571, 0x00b5b5, 0x0063b5, 0x0000b5, 0x7500b5, 0xb500b5, 0xb5006b,
+	0xff0000, 0xff8c00, 0xffff00, 0xb2ff00, 0x00ff00, 0x00ffa0, 0x00ffff, 0x008cff, 0x0000ff, 0xa500ff, 0xff00ff, 0xff0098,
+	0xff5959, 0xffb459, 0xffff71, 0xcfff60, 0x6fff6f, 0x65ffc9, 0x6dffff, 0x59b4ff, 0x5959ff, 0xc459ff, 0xff66ff, 0xff59bc,
+	0xff9c9c, 0xffd39c, 0xffff9c, 0xe2ff9c, 0x9cff9c, 0x9cffdb, 0x9cffff, 0x9cd3ff, 0x9c9cff, 0xdc9cff, 0xff9cff, 0xff94d3,
+	0x000000, 0x131313, 0x282828, 0x363636, 0x4d4d4d, 0x656565, 0x818181, 0x9f9f9f, 0xbcbcbc, 0xe2e2e2, 0xffffff,
+}
+
+// Color is set with a mIRC color code or a hex color. The zero Color is the
+// default color of the terminal or document.
+type Color struct {
+	Set   bool
+	Code  int    // mIRC color code or -1 for a hex color
+	Value uint32 // 0xRRGGBB
+}
+
+// Hex returns the color as "#rrggbb"
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%06x", c.Value)
+}
+
+// Style is the formatting of a Span
+type Style struct {
+	Bold, Italic, Underline, Strikethrough, Monospace, Reverse bool
+
+	Fg, Bg Color
+}
+
+// Span is a piece of text with the same Style
+type Span struct {
+	Style
+	Text string
+}
+
+// Parse splits s into Spans of differently formatted text
+func Parse(s string) []Span {
+	var (
+		spans []Span
+		style Style
+		text  []byte
+	)
+	flush := func() {
+		if len(text) > 0 {
+			spans = append(spans, Span{Style: style, Text: string(text)})
+			text = text[:0]
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case Bold, Italic, Underline, Strikethrough, Monospace, Reverse, Reset:
+			flush()
+			switch c {
+			case Bold:
+				style.Bold = !style.Bold
+			case Italic:
+				style.Italic = !style.Italic
+			case Underline:
+				style.Underline = !style.Underline
+			case Strikethrough:
+				style.Strikethrough = !style.Strikethrough
+			case Monospace:
+				style.Monospace = !style.Monospace
+			case Reverse:
+				style.Reverse = !style.Reverse
+			case Reset:
+				style = Style{}
+			}
+
+		case ColorCode:
+			flush()
+			fg, n := code(s[i+1:])
+			if n == 0 {
+				style.Fg, style.Bg = Color{}, Color{}
+				continue
+			}
+			i += n
+			style.Fg = fg
+			if i+2 < len(s) && s[i+1] == ',' && isDigit(s[i+2]) {
+				bg, n := code(s[i+2:])
+				style.Bg = bg
+				i += 1 + n
+			}
+
+		case HexColorCode:
+			flush()
+			fg, ok := hex(s[i+1:])
+			if !ok {
+				style.Fg, style.Bg = Color{}, Color{}
+				continue
+			}
+			i += 6
+			style.Fg = fg
+			if i+1 < len(s) && s[i+1] == ',' {
+				if bg, ok := hex(s[i+2:]); ok {
+					style.Bg = bg
+					i += 1 + 6
+				}
+			}
+
+		default:
+			text = append(text, c)
+		}
+	}
+	flush()
+	return spans
+}
+
+// code parses a mIRC color code of up to two digits and returns its length
+func code(s string) (Color, int) {
+	n := 0
+	for n < 2 && n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	if n == 0 {
+		return Color{}, 0
+	}
+	i, _ := strconv.Atoi(s[:n])
+	if i >= len(palette) {
+		// 99 is the default color
+		return Color{}, n
+	}
+	return Color{Set: true, Code: i, Value: palette[i]}, n
+}
+
+// hex parses a 6 digit hex color
+func hex(s string) (Color, bool) {
+	if len(s) < 6 {
+		return Color{}, false
+	}
+	v, err := strconv.ParseUint(s[:6], 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return Color{Set: true, Code: -1, Value: uint32(v)}, true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Strip removes all formatting codes from s
+func Strip(s string) string {
+	var str string
+	for _, sp := range Parse(s) {
+		str += sp.Text
+	}
+	return str
+}
+
+// ANSI renders the formatting of s as ANSI terminal escapes with 24 bit colors
+func ANSI(s string) string {
+	var str string
+	for _, sp := range Parse(s) {
+		var sgr []string
+		if sp.Bold {
+			sgr = append(sgr, "1")
+		}
+		if sp.Italic {
+			sgr = append(sgr, "3")
+		}
+		if sp.Underline {
+			sgr = append(sgr, "4")
+		}
+		if sp.Reverse {
+			sgr = append(sgr, "7")
+		}
+		if sp.Strikethrough {
+			sgr = append(sgr, "9")
+		}
+		if sp.Fg.Set {
+			sgr = append(sgr, "38;2;"+rgbParams(sp.Fg.Value))
+		}
+		if sp.Bg.Set {
+			sgr = append(sgr, "48;2;"+rgbParams(sp.Bg.Value))
+		}
+
+		if len(sgr) == 0 {
+			str += sp.Text
+			continue
+		}
+		str += "\x1b[" + strings.Join(sgr, ";") + "m" + sp.Text + "\x1b[0m"
+	}
+	return str
+}
+
+func rgbParams(c uint32) string {
+	return fmt.Sprintf("%d;%d;%d", c>>16&0xff, c>>8&0xff, c&0xff)
+}
+
+// HTML renders s as HTML with the formatting as styled <span> elements
+func HTML(s string) string {
+	var str string
+	for _, sp := range Parse(s) {
+		var css []string
+		if sp.Bold {
+			css = append(css, "font-weight:bold")
+		}
+		if sp.Italic {
+			css = append(css, "font-style:italic")
+		}
+		switch {
+		case sp.Underline && sp.Strikethrough:
+			css = append(css, "text-decoration:underline line-through")
+		case sp.Underline:
+			css = append(css, "text-decoration:underline")
+		case sp.Strikethrough:
+			css = append(css, "text-decoration:line-through")
+		}
+		if sp.Monospace {
+			css = append(css, "font-family:monospace")
+		}
+
+		fg, bg := sp.Fg, sp.Bg
+		if sp.Reverse {
+			if !fg.Set {
+				fg = Color{Set: true, Code: 1, Value: palette[1]}
+			}
+			if !bg.Set {
+				bg = Color{Set: true, Code: 0, Value: palette[0]}
+			}
+			fg, bg = bg, fg
+		}
+		if fg.Set {
+			css = append(css, "color:"+fg.Hex())
+		}
+		if bg.Set {
+			css = append(css, "background-color:"+bg.Hex())
+		}
+
+		text := html.EscapeString(sp.Text)
+		if len(css) == 0 {
+			str += text
+			continue
+		}
+		str += `<span style="` + strings.Join(css, ";") + `">` + text + "</span>"
+	}
+	return str
+}
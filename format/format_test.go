@@ -0,0 +1,50 @@
+package format
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	spans := Parse("a\x02b\x0304,12c\x03,d\x1d\x0f\x04ff8800e")
+	want := []Span{
+		{Text: "a"},
+		{Style: Style{Bold: true}, Text: "b"},
+		{Style: Style{Bold: true, Fg: Color{true, 4, 0xff0000}, Bg: Color{true, 12, 0x0000fc}}, Text: "c"},
+		{Style: Style{Bold: true}, Text: ",d"},
+		{Style: Style{Fg: Color{true, -1, 0xff8800}}, Text: "e"},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("got %d spans want %d: %#v", len(spans), len(want), spans)
+	}
+	for i := range want {
+		if spans[i] != want[i] {
+			t.Errorf("span %d got %#v want %#v", i, spans[i], want[i])
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":                    "plain",
+		"\x02bold\x02 text":        "bold text",
+		"\x0312,04colored\x03":     "colored",
+		"\x034,5,6":                ",6",
+		"\x0399default":            "default",
+		"\x041a2b3c,ffffffhex":     "hex",
+		"\x04nohex":                "nohex",
+		"\x1d\x1f\x1e\x11\x16\x0f": "",
+	} {
+		if got := Strip(in); got != want {
+			t.Errorf("Strip(%q) got %q want %q", in, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	if got := ANSI("a\x02\x034b"); got != "a\x1b[1;38;2;255;0;0mb\x1b[0m" {
+		t.Errorf("ANSI got %q", got)
+	}
+	if got := HTML("<\x1f\x0302x"); got != `&lt;<span style="text-decoration:underline;color:#00007f">x</span>` {
+		t.Errorf("HTML got %q", got)
+	}
+}
@@ -1,8 +1,17 @@
 package irc
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 type testMsg struct {
@@ -60,6 +69,1133 @@ func TestMode(t *testing.T) {
 	}
 }
 
+func TestSendQueue(t *testing.T) {
+	c := &Client{nick: "me"}
+	q := newSendQueue(2, c.textBudget)
+	q.Push(Msg("#a", "1"))
+	q.Push(Msg("#a", "2"))
+	q.Push(Msg("#b", "3"))
+	q.Push(Join("#c"))
+	q.Push(Message{Command: "PONG", Trailing: "x"})
+	q.Push(Msg("#a", "4")) // coalesced with "2"
+
+	want := []string{"PONG", "JOIN #c", "#a 1", "#b 3", "#a 2 4"}
+	for _, w := range want {
+		m, ok := q.Pop()
+		if !ok {
+			t.Fatalf("queue empty want %q", w)
+		}
+		var got string
+		if m.Command == "PRIVMSG" {
+			got = m.Parms[0] + " " + m.Trailing
+		} else {
+			got = strings.TrimSpace(m.Command + " " + m.Parms.String())
+		}
+		if got != w {
+			t.Fatalf("got %q want %q", got, w)
+		}
+	}
+	if q.Len() != 0 {
+		t.Fatalf("queue shoud be empty got %d", q.Len())
+	}
+
+	// CTCPs and lines over the budget are not coalesced
+	long := strings.Repeat("x", c.textBudget("PRIVMSG", "#a")/2+1)
+	for _, m := range []Message{Msg("#a", "1"), Msg("#a", long), Msg("#a", long), Action("#a", "waves"), Action("#a", "dances")} {
+		q.Push(m)
+	}
+	for q.Len() > 0 {
+		m, _ := q.Pop()
+		if m.Validate() != nil || len(m.Trailing) > c.textBudget("PRIVMSG", "#a") {
+			t.Fatalf("coalesced %q over budget", m.Trailing)
+		}
+		if strings.Count(m.Trailing, "\x01") > 2 {
+			t.Fatalf("coalesced CTCP %q", m.Trailing)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	m, err := ParseMessage([]byte("@a=b\\\\and\\nk;c=72\\s45;d=gh\\:764;e :nick!u@h PRIVMSG #c :hi\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Tags{"a": "b\\and\nk", "c": "72 45", "d": "gh;764", "e": ""}
+	if m.Tags.String() != want.String() {
+		t.Fatalf("tags not the same got %q want %q", m.Tags, want)
+	}
+	if m.Tags["d"] != "gh;764" {
+		t.Fatalf("tag d got %q", m.Tags["d"])
+	}
+	if str := m.String(); str != "@a=b\\\\and\\nk;c=72\\s45;d=gh\\:764;e :nick!u@h PRIVMSG #c :hi\r\n" {
+		t.Fatalf("got %q", str)
+	}
+}
+
+func TestServerTime(t *testing.T) {
+	m, err := ParseMessage([]byte("@time=2011-10-19T16:40:51.620Z :nick!u@h PRIVMSG #c :hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2011, 10, 19, 16, 40, 51, 620e6, time.UTC)
+	if !m.Time.Equal(want) {
+		t.Fatalf("got time %s want %s", m.Time, want)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{"hello world", 20, []string{"hello world"}},
+		{"hello world foo", 11, []string{"hello world", "foo"}},
+		{"hello world foo", 8, []string{"hello", "world", "foo"}},
+		{"abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"äöü", 3, []string{"ä", "ö", "ü"}},
+		{"ab\x0312,04cd", 4, []string{"ab", "\x0312,04", "cd"}},
+		{"a\x0312,04cd", 5, []string{"a", "\x0312,04", "cd"}},
+		{"\x04ff00aa,bad idea", 8, []string{"\x04ff00aa,", "bad idea"}},
+		{"\x04ff00aa,bad idea", 5, []string{"\x04ff00aa", ",bad", "idea"}},
+		{"\x04ff0 x", 4, []string{"\x04ff0", "x"}},
+	} {
+		got := SplitText(test.text, test.max)
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", test.want) {
+			t.Errorf("SplitText(%q, %d) got %q want %q", test.text, test.max, got, test.want)
+		}
+		if concat := strings.Join(splitText(test.text, test.max, true), ""); concat != test.text {
+			t.Errorf("splitText(%q, %d, true) concatenated to %q", test.text, test.max, concat)
+		}
+	}
+}
+
+func TestSay(t *testing.T) {
+	c := newClient(Config{Nick: "me"})
+	if err := c.Say("#c", "one\r\n\ntwo\n"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"PRIVMSG #c :one\r\n", "PRIVMSG #c :two\r\n"} {
+		if m := <-c.send; m.String() != want {
+			t.Fatalf("got %q want %q", m, want)
+		}
+	}
+	if len(c.send) != 0 {
+		t.Fatalf("empty line sent: %q", <-c.send)
+	}
+}
+
+func TestMultilineLimits(t *testing.T) {
+	b, l := multilineLimits("max-bytes=4096,max-lines=24")
+	if b != 4096 || l != 24 {
+		t.Fatalf("got max-bytes %d max-lines %d", b, l)
+	}
+	if b, l := multilineLimits("max-bytes=512"); b != 512 || l != 0 {
+		t.Fatalf("got max-bytes %d max-lines %d", b, l)
+	}
+}
+
+func TestMultiline(t *testing.T) {
+	c := newClient(Config{Nick: "me"})
+	c.caps = map[string]string{"draft/multiline": "max-lines=2"}
+	c.capsEnabled = map[string]bool{"draft/multiline": true, "labeled-response": true}
+	if err := c.Multiline("#c", "a\n\nb"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"@label=l1 BATCH +ml1 draft/multiline #c\r\n",
+		"@batch=ml1 PRIVMSG #c :a\r\n",
+		"@batch=ml1 PRIVMSG #c :\r\n",
+		"BATCH -ml1\r\n",
+		"@label=l2 BATCH +ml2 draft/multiline #c\r\n",
+		"@batch=ml2 PRIVMSG #c :b\r\n",
+		"BATCH -ml2\r\n",
+	} {
+		if m := <-c.send; m.String() != want {
+			t.Fatalf("got %q want %q", m, want)
+		}
+	}
+	if len(c.send) != 0 {
+		t.Fatalf("unexpected %q", <-c.send)
+	}
+}
+
+func TestCollectBatch(t *testing.T) {
+	c := &Client{batches: make(map[string]Message)}
+	var got []Message
+	for _, raw := range []string{
+		":srv BATCH +a netsplit irc.hub other.host",
+		"@batch=a :x!u@h QUIT :irc.hub other.host",
+		"@batch=a :srv BATCH +b chathistory #c",
+		"@batch=b :y!u@h PRIVMSG #c :hi",
+		":srv BATCH -b",
+		":srv BATCH -a",
+		":z!u@h PRIVMSG #c :after",
+	} {
+		m, err := ParseMessage([]byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m, ok := c.collectBatch(m); ok {
+			got = append(got, m)
+		}
+	}
+
+	if len(got) != 2 || got[1].Trailing != "after" {
+		t.Fatalf("got %d messages want 2: %v", len(got), got)
+	}
+	b := got[0].Batch
+	switch {
+	case b == nil:
+		t.Fatal("batch not set")
+	case b.Type != "netsplit" || b.Parms.String() != "irc.hub other.host ":
+		t.Fatalf("got batch type %q parms %q", b.Type, b.Parms)
+	case len(b.Messages) != 2 || b.Messages[0].Command != "QUIT":
+		t.Fatalf("got batch messages %v", b.Messages)
+	case b.Messages[1].Batch == nil || len(b.Messages[1].Batch.Messages) != 1:
+		t.Fatalf("nested batch not collected: %v", b.Messages[1])
+	}
+}
+
+func TestDeliverLabeled(t *testing.T) {
+	res := make(chan []Message, 1)
+	c := &Client{labels: map[string]chan []Message{"l1": res}}
+
+	m, _ := ParseMessage([]byte("@label=l2 :srv ACK"))
+	if c.deliverLabeled(m) {
+		t.Fatal("delivered unknown label")
+	}
+	m, _ = ParseMessage([]byte("@label=l1 :srv 433 * nick :Nickname is already in use"))
+	if !c.deliverLabeled(m) {
+		t.Fatal("label not delivered")
+	}
+	if ms := <-res; len(ms) != 1 || ms[0].Command != "433" {
+		t.Fatalf("got response %v", ms)
+	}
+	if _, ok := c.labels["l1"]; ok {
+		t.Fatal("label not removed")
+	}
+}
+
+func TestStandardReply(t *testing.T) {
+	m, _ := ParseMessage([]byte(":srv FAIL CHATHISTORY INVALID_TARGET #c * :Messages could not be retrieved"))
+	r, ok := ParseStandardReply(m)
+	if !ok || r.Type != "FAIL" || r.Command != "CHATHISTORY" || r.Code != "INVALID_TARGET" ||
+		len(r.Context) != 2 || r.Context[0] != "#c" || r.Description != "Messages could not be retrieved" {
+		t.Fatalf("got %#v", r)
+	}
+	err := responseErr([]Message{m})
+	if !errors.Is(err, &StandardReply{Code: "INVALID_TARGET"}) || errors.Is(err, &StandardReply{Command: "JOIN", Code: "INVALID_TARGET"}) {
+		t.Fatalf("errors.Is failed for %v", err)
+	}
+	if got := err.Error(); got != "FAIL CHATHISTORY INVALID_TARGET #c *: Messages could not be retrieved" {
+		t.Fatalf("got %q", got)
+	}
+
+	m, _ = ParseMessage([]byte(":srv WARN REHASH CERTS_EXPIRED :Certificate has expired"))
+	if r, ok := ParseStandardReply(m); !ok || r.Type != "WARN" || r.Context != nil || m.Err() != nil {
+		t.Fatalf("got %#v", r)
+	}
+	if _, ok := ParseStandardReply(Message{Command: "NOTE", Parms: Parms{"*"}}); ok {
+		t.Fatal("parsed NOTE without code")
+	}
+}
+
+func TestServerError(t *testing.T) {
+	m, _ := ParseMessage([]byte(":srv 474 me #chan :Cannot join channel (+b)"))
+	err := responseErr([]Message{Join("#chan"), m})
+	if !errors.Is(err, ErrBannedFromChan) || errors.Is(err, ErrNickInUse) {
+		t.Fatalf("errors.Is failed for %v", err)
+	}
+	var se *ServerError
+	if !errors.As(err, &se) || se.Code != "474" || se.Name != "ERR_BANNEDFROMCHAN" || se.Target != "#chan" || se.Text != "Cannot join channel (+b)" {
+		t.Fatalf("got %#v", se)
+	}
+	if got := err.Error(); got != "ERR_BANNEDFROMCHAN #chan: Cannot join channel (+b)" {
+		t.Fatalf("got %q", got)
+	}
+	m, _ = ParseMessage([]byte(":srv 599 me :unknown error"))
+	if err := m.Err(); err == nil || err.Error() != "599: unknown error" {
+		t.Fatalf("got %v", err)
+	}
+	if err := Msg("#c", "hi").Err(); err != nil {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestPresence(t *testing.T) {
+	c := &Client{Events: make(chan Event, 4)}
+	p := NewPresence(c, "Alice", "bob")
+	p.pending = [][]string{{"Alice", "bob"}}
+
+	for _, raw := range []string{
+		":srv 303 me :alice",
+		":srv 730 me :bob!u@h",
+		":srv 731 me :Alice",
+	} {
+		m, _ := ParseMessage([]byte(raw))
+		if !p.presenceControl(m) {
+			t.Fatalf("%q not handled", raw)
+		}
+	}
+
+	want := []PresenceEvent{
+		{Nick: "Alice", Online: true},
+		{Nick: "bob", Prefix: Prefix{"bob", "u", "h"}, Online: true},
+		{Nick: "Alice", Online: false},
+	}
+	for _, w := range want {
+		if e := <-c.Events; e != w {
+			t.Fatalf("got event %#v want %#v", e, w)
+		}
+	}
+	if !p.Online("BOB") || p.Online("alice") {
+		t.Fatal("wrong online state")
+	}
+}
+
+func TestPresenceISON(t *testing.T) {
+	c := newClient(Config{Nick: "me"})
+	p := NewPresence(c, "bob")
+	p.ison()
+	if len(p.pending) != 1 {
+		t.Fatalf("got %d pending queries want 1", len(p.pending))
+	}
+
+	// the reply to an ISON of the user is passed on
+	m, _ := ParseMessage([]byte(":srv 303 me :carol"))
+	if p.presenceControl(m) || len(p.pending) != 1 {
+		t.Fatal("foreign RPL_ISON taken")
+	}
+
+	// a failed Send leaves nothing pending
+	p.pending = nil
+	c.cancel()
+	p.ison()
+	if len(p.pending) != 0 {
+		t.Fatalf("got %d pending queries after failed Send", len(p.pending))
+	}
+}
+
+func TestChannelManagerUsers(t *testing.T) {
+	cm := NewCM(&Client{nick: "me"})
+	for _, raw := range []string{
+		":me!u@h JOIN #c",
+		":bob!b@old JOIN #c bobacc :Bob Real",
+		":bob!b@old AWAY :gone fishing",
+		":bob!b@old ACCOUNT *",
+		":bob!b@old CHGHOST nb new.host",
+		":bob!nb@new.host NICK :robert",
+	} {
+		m, _ := ParseMessage([]byte(raw))
+		cm.ServeIRC(m, nil)
+	}
+
+	want := User{Nick: "robert", User: "nb", Host: "new.host", Realname: "Bob Real", Away: true, AwayMessage: "gone fishing"}
+	if u, ok := cm.User("robert"); !ok || u != want {
+		t.Fatalf("got user %#v want %#v", u, want)
+	}
+
+	m, _ := ParseMessage([]byte(":robert!nb@new.host PART #c"))
+	cm.ServeIRC(m, nil)
+	if _, ok := cm.User("robert"); ok {
+		t.Fatal("user not removed after PART")
+	}
+}
+
+func TestChannelManagerConcurrent(t *testing.T) {
+	cm := NewCM(&Client{nick: "me"})
+	join, _ := ParseMessage([]byte(":me!u@h JOIN #c"))
+	cm.ServeIRC(join, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			for _, raw := range []string{":bob!b@h JOIN #c", ":op!o@h MODE #c +v bob", ":op!o@h MODE #c +n", ":bob!b@h PART #c"} {
+				m, _ := ParseMessage([]byte(raw))
+				cm.ServeIRC(m, nil)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		cm.User("bob")
+		for _, ch := range cm.List() {
+			ch.Names()
+			ch.Mode()
+		}
+	}
+	<-done
+}
+
+func TestCTCP(t *testing.T) {
+	m, _ := ParseMessage([]byte(":nick!u@h PRIVMSG me :\x01ping 123\x10n\x01"))
+	q, ok := ParseCTCP(m)
+	if !ok || q.Command != "PING" || q.Params != "123\n" {
+		t.Fatalf("got %#v %v", q, ok)
+	}
+	if s := LowDequote(LowQuote("a\x00b\r\n\x10")); s != "a\x00b\r\n\x10" {
+		t.Fatalf("quoting round trip got %q", s)
+	}
+	if _, ok := ParseCTCP(Msg("#c", "hi")); ok {
+		t.Fatal("found CTCP in plain PRIVMSG")
+	}
+
+	c := &Client{Events: make(chan Event, 1)}
+	r := NewCTCPResponder(c)
+	res := make(chan Message, 1)
+	if !r.ServeIRC(m, res) {
+		t.Fatal("CTCP not consumed")
+	}
+	if rep := <-res; rep.String() != "NOTICE nick :\x01PING 123\x10n\x01\r\n" {
+		t.Fatalf("got reply %q", rep)
+	}
+	if e := (<-c.Events).(CTCPEvent); e.Command != "PING" || e.Reply {
+		t.Fatalf("got event %#v", e)
+	}
+}
+
+func TestCharset(t *testing.T) {
+	if s := CP1252.Decode([]byte("\x80 caf\xe9 \x93x\x94")); s != "€ café “x”" {
+		t.Fatalf("CP1252 decode got %q", s)
+	}
+	if b := CP1252.Encode("€ café ☃"); string(b) != "\x80 caf\xe9 ?" {
+		t.Fatalf("CP1252 encode got %q", b)
+	}
+	if s := Latin9.Decode([]byte("\xa4")); s != "€" {
+		t.Fatalf("Latin9 decode got %q", s)
+	}
+
+	c := &Client{cfg: Config{Fallback: Latin1}, charsets: map[string]Charset{"#legacy": CP1252}}
+	for raw, want := range map[string]string{
+		":n!u@h PRIVMSG #c :caf\xe9":           "café",
+		":n!u@h PRIVMSG #Legacy :\x80":         "€",
+		":n!u@h PRIVMSG #c :café \xe2\x98\x83": "café ☃",
+	} {
+		m, _ := ParseMessage([]byte(raw))
+		m.Raw = []byte(raw)
+		c.decode(&m)
+		if m.Trailing != want {
+			t.Errorf("decode %q got %q want %q", raw, m.Trailing, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, m := range []Message{
+		Msg("#c", "hi"),
+		Msg("#c", ""),
+		{Command: "MODE", Parms: Parms{"#c", "+o", "nick"}},
+		{Command: "USER", Parms: Parms{"u", "0", "*", "real name"}},
+		{Tags: Tags{"+draft/reply": "x y"}, Command: "TAGMSG", Parms: Parms{"#c"}},
+		{Command: "001", Parms: Parms{"me"}, Trailing: "welcome"},
+		Userhost("a", "b", "c", "d", "e"),
+	} {
+		if err := m.Validate(); err != nil {
+			t.Errorf("%q: %s", m, err)
+		}
+	}
+
+	for _, m := range []Message{
+		{Command: ""},
+		{Command: "PRIV MSG"},
+		{Command: "01"},
+		Msg("#c", "hi\r\nQUIT :injected"),
+		{Command: "KICK", Parms: Parms{"#c", "a b"}, Trailing: "reason"},
+		{Command: "KICK", Parms: Parms{"#c", ""}, Trailing: "reason"},
+		{Command: "PRIVMSG", Parms: Parms{":#c"}, Trailing: "x"},
+		{Command: "X", Parms: Parms{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16"}},
+		{Tags: Tags{"a b": ""}, Command: "TAGMSG"},
+		Msg("#c", strings.Repeat("x", MaxLineLength)),
+		Userhost("a", "b", "c", "d", "e", "f"),
+	} {
+		err := m.Validate()
+		if _, ok := err.(*ValidationError); !ok {
+			t.Errorf("%q: got %v want *ValidationError", m, err)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	in := "PING :a\r\n\nPING :b\n" + "PING :" + strings.Repeat("x", 20) + "\r\nPING c"
+	dec := NewDecoder(strings.NewReader(in))
+	dec.MaxLength = 16
+
+	for _, want := range []string{"a", "b", "", "c"} {
+		m, err := dec.Decode()
+		if want == "" {
+			if err != ErrLineTooLong {
+				t.Fatalf("got %v want ErrLineTooLong", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Command != "PING" || lastParm(m) != want || string(m.Raw) != "PING :"+want && string(m.Raw) != "PING "+want {
+			t.Fatalf("got %#v want PING %q", m, want)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("got %v want EOF", err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var b bytes.Buffer
+	enc := NewEncoder(&b)
+	if err := enc.Encode(Msg("#c", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	enc.Charset = Latin1
+	if err := enc.Encode(Msg("#c", "café")); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(Msg("#c", "a\nb")); err == nil {
+		t.Fatal("encoded invalid message")
+	}
+	if b.String() != "PRIVMSG #c :hi\r\nPRIVMSG #c :caf\xe9\r\n" {
+		t.Fatalf("got %q", b.String())
+	}
+}
+
+func TestCommands(t *testing.T) {
+	for _, test := range []struct {
+		m    Message
+		want string
+	}{
+		{Nick("bot"), "NICK bot"},
+		{Register("bot", "The Bot"), "USER bot 0 * :The Bot"},
+		{Part("#c", ""), "PART #c"},
+		{Part("#c", "bye"), "PART #c :bye"},
+		{Kick("n", "#c", "out"), "KICK #c n :out"},
+		{SetTopic("#c", ""), "TOPIC #c :"},
+		{SetMode("#c", "+lk", "10", "key"), "MODE #c +lk 10 key"},
+		{JoinKey("#c", "key"), "JOIN #c key"},
+		{Names(), "NAMES"},
+		{Names("#a", "#b"), "NAMES #a,#b"},
+		{Who("*.fi", true), "WHO *.fi o"},
+		{Whowas("n", 0), "WHOWAS n"},
+		{Away(""), "AWAY"},
+		{Quit("bye bye"), "QUIT :bye bye"},
+		{Lusers("", "srv"), "LUSERS"},
+		{Connect("srv", 0, ""), "CONNECT srv"},
+	} {
+		if err := test.m.Validate(); err != nil {
+			t.Errorf("%q: %s", test.want, err)
+		}
+		if got := test.m.String(); got != test.want+"\r\n" {
+			t.Errorf("got %q want %q", got, test.want)
+		}
+	}
+	if err := Nick("a b").Validate(); err == nil {
+		t.Error("nick with space is valid")
+	}
+
+	is := ISupport{"TARGMAX": "PRIVMSG:4,JOIN:2", "MODES": "2"}
+	var got []string
+	for _, m := range JoinAll(is, []string{"#a", "#b", "#c"}, []string{"", "kb"}) {
+		got = append(got, strings.TrimSpace(m.String()))
+	}
+	if want := "JOIN #b,#a kb|JOIN #c"; strings.Join(got, "|") != want {
+		t.Errorf("JoinAll got %q want %q", got, want)
+	}
+
+	got = got[:0]
+	for _, m := range MassOp(is, "#c", "a", "b", "c") {
+		got = append(got, strings.TrimSpace(m.String()))
+	}
+	if want := "MODE #c +oo a b|MODE #c +o c"; strings.Join(got, "|") != want {
+		t.Errorf("MassOp got %q want %q", got, want)
+	}
+	nicks := make([]string, 100)
+	for i := range nicks {
+		nicks[i] = fmt.Sprintf("nick%02d", i)
+	}
+	n := 0
+	for _, m := range MassVoice(ISupport{"MODES": ""}, "#c", nicks...) {
+		if err := m.Validate(); err != nil {
+			t.Errorf("unlimited MODES: %s", err)
+		}
+		n += len(m.Parms) - 2
+	}
+	if n != len(nicks) {
+		t.Errorf("unlimited MODES voiced %d nicks", n)
+	}
+	if n := (ISupport{}).Modes(); n != 3 {
+		t.Errorf("default MODES got %d", n)
+	}
+}
+
+func TestNumerics(t *testing.T) {
+	n, ok := LookupNumeric("433")
+	if !ok || n.Code != ErrNICKNAMEINUSE || n.Name != "ERR_NICKNAMEINUSE" || n.Description != "<nick> :Nickname is already in use" {
+		t.Errorf("got %#v, %v", n, ok)
+	}
+	if n, ok := LookupNumeric(RplWELCOME); !ok || n.Name != "RPL_WELCOME" {
+		t.Errorf("001 got %#v, %v", n, ok)
+	}
+	if _, ok := LookupNumeric("1"); ok {
+		t.Error("found numeric \"1\"")
+	}
+
+	c := &Client{nick: "longnick", isupport: make(ISupport)}
+	c.track(Message{Prefix: Prefix{Host: "srv"}, Command: RplWELCOME, Parms: Parms{"longni"}, Trailing: "Welcome"})
+	c.track(Message{Prefix: Prefix{Host: "srv"}, Command: RplVISIBLEHOST, Parms: Parms{"longni", "cloak/host"}, Trailing: "is now your displayed host"})
+	if c.nick != "longni" || c.prefix.String() != "longni@cloak/host" {
+		t.Errorf("got nick %q prefix %q", c.nick, c.prefix)
+	}
+}
+
+func TestLag(t *testing.T) {
+	c := &Client{}
+	ping, ok := c.ping()
+	if !ok || ping.Command != "PING" || ping.Validate() != nil {
+		t.Fatalf("got %v, %v", ping, ok)
+	}
+	if _, ok := c.ping(); ok {
+		t.Fatal("second PING while waiting for PONG")
+	}
+	if c.pong(Message{Command: "PONG", Parms: Parms{"srv"}, Trailing: "other"}) {
+		t.Fatal("PONG with other token accepted")
+	}
+	time.Sleep(time.Millisecond)
+	pong, _ := ParseMessage([]byte(":srv PONG srv :" + ping.Parms[0]))
+	if !c.pong(pong) || c.Lag() < time.Millisecond {
+		t.Fatalf("lag got %s", c.Lag())
+	}
+	if c.pingTimedOut() {
+		t.Fatal("timed out after PONG")
+	}
+	c.ping()
+	if !c.pingTimedOut() {
+		t.Fatal("no timeout without PONG")
+	}
+}
+
+func TestReconnectPolicy(t *testing.T) {
+	p := ReconnectPolicy{MinDelay: time.Second, MaxDelay: 5 * time.Second, Jitter: -1}.withDefaults()
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := p.delay(i + 1); d != want {
+			t.Errorf("attempt %d got %s want %s", i+1, d, want)
+		}
+	}
+
+	p = ReconnectPolicy{}.withDefaults()
+	if p.MinDelay != DefaultReconnectMinDelay || p.Jitter != DefaultReconnectJitter {
+		t.Fatalf("got %#v", p)
+	}
+	for i := 0; i < 100; i++ {
+		if d := p.delay(1); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("jitter out of range got %s", d)
+		}
+	}
+}
+
+// testServer registers the clients connecting to it and answers QUIT with
+// ERROR, it does not answer PINGs. With echo-message in caps every PRIVMSG is
+// echoed and answered by "other".
+type testServer struct {
+	ln    net.Listener
+	lines chan string   // all received lines
+	conns chan net.Conn // accepted connections
+	caps  string        // offered and acknowledged capabilities
+	nick  string        // reply to NICK instead of the welcome
+}
+
+func newTestServer(t *testing.T) *testServer {
+	return newTestServerOn(t, "tcp4", "127.0.0.1:0")
+}
+
+func newTestServerOn(t *testing.T, network, address string) *testServer {
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &testServer{ln: ln, lines: make(chan string, 100), conns: make(chan net.Conn, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns <- conn
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+	dec := NewDecoder(conn)
+	var nick string
+	welcome := func() {
+		io.WriteString(conn, ":srv 001 "+nick+" :Welcome\r\n:srv 376 "+nick+" :End of MOTD\r\n")
+	}
+	for {
+		m, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		s.lines <- string(m.Raw)
+		switch m.Command {
+		case "CAP":
+			switch m.Parms[0] {
+			case "LS":
+				io.WriteString(conn, ":srv CAP * LS :"+s.caps+"\r\n")
+			case "REQ":
+				io.WriteString(conn, ":srv CAP * ACK :"+m.Trailing+"\r\n")
+			case "END":
+				welcome()
+			}
+		case "NICK":
+			nick = m.Parms[0]
+			if s.nick != "" {
+				io.WriteString(conn, s.nick+"\r\n")
+			} else if s.caps == "" {
+				welcome()
+			}
+		case "JOIN":
+			if strings.Contains(s.caps, "labeled-response") {
+				io.WriteString(conn, "@label="+m.Tags["label"]+" :srv BATCH +j labeled-response\r\n"+
+					"@batch=j :"+nick+"!u@h JOIN "+m.Parms[0]+"\r\n"+
+					"@batch=j :srv 353 "+nick+" = "+m.Parms[0]+" :"+nick+" other\r\n"+
+					"@batch=j :srv 366 "+nick+" "+m.Parms[0]+" :End of NAMES\r\n"+
+					":srv BATCH -j\r\n")
+			}
+		case "PRIVMSG":
+			if strings.Contains(s.caps, "echo-message") {
+				m.Prefix = Prefix{nick, "u", "h"}
+				io.WriteString(conn, m.String())
+				io.WriteString(conn, ":other!u@h PRIVMSG "+m.Parms[0]+" :re "+m.Trailing+"\r\n")
+			}
+		case "QUIT":
+			io.WriteString(conn, "ERROR :Closing link\r\n")
+			return
+		}
+	}
+}
+
+// expect waits for the line want
+func (s *testServer) expect(t *testing.T, want string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line := <-s.lines:
+			if line == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("%q not received", want)
+		}
+	}
+}
+
+// expectEvent waits for an Event of the type of want
+func expectEvent(t *testing.T, c *Client, want Event) Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-c.Events:
+			if fmt.Sprintf("%T", e) == fmt.Sprintf("%T", want) {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("no %T", want)
+		}
+	}
+}
+
+func fastSend(t *testing.T) {
+	interval := sendInterval
+	sendInterval = time.Millisecond
+	t.Cleanup(func() { sendInterval = interval })
+}
+
+func TestClientClose(t *testing.T) {
+	fastSend(t)
+	srv := newTestServer(t)
+	c, err := DialConfig(srv.ln.Addr().String(), Config{Nick: "bot", PingInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(Msg("#c", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	srv.expect(t, "PRIVMSG #c :hi")
+
+	if err := c.Close("bye"); err != nil {
+		t.Fatal(err)
+	}
+	srv.expect(t, "QUIT :bye")
+	select {
+	case <-c.Done:
+	default:
+		t.Fatal("Done not closed after Close")
+	}
+	for range c.Msg {
+	}
+	if err := c.Send(Msg("#c", "hi")); err != ErrClosed {
+		t.Fatalf("Send after Close got %v", err)
+	}
+	if err := c.Close("again"); err != ErrClosed {
+		t.Fatalf("second Close got %v", err)
+	}
+}
+
+func TestCloseFromHandler(t *testing.T) {
+	fastSend(t)
+	conn, server := net.Pipe()
+	srv := &testServer{lines: make(chan string, 100)}
+	go srv.serve(server)
+	c, err := NewClient(conn, Config{Nick: "bot", PingInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan error, 1)
+	c.HandleFunc(func(req Message, res chan<- Message) bool {
+		if req.Command == "PRIVMSG" {
+			closed <- c.Close("bye")
+		}
+		return true
+	})
+
+	io.WriteString(server, ":n!u@h PRIVMSG bot :quit\r\n")
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked in the Handler")
+	}
+	srv.expect(t, "QUIT :bye")
+	select {
+	case <-c.Done:
+	case <-time.After(time.Second):
+		t.Fatal("Client not closed after the Handler returned")
+	}
+}
+
+func TestCloseWhileHandling(t *testing.T) {
+	fastSend(t)
+	conn, server := net.Pipe()
+	srv := &testServer{lines: make(chan string, 100)}
+	go srv.serve(server)
+	c, err := NewClient(conn, Config{Nick: "bot", PingInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handling := make(chan struct{})
+	c.HandleFunc(func(req Message, res chan<- Message) bool {
+		if req.Command == "PRIVMSG" {
+			close(handling)
+			time.Sleep(300 * time.Millisecond)
+		}
+		return true
+	})
+
+	io.WriteString(server, ":n!u@h PRIVMSG bot :slow\r\n")
+	<-handling
+	if err := c.Close("bye"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.Done:
+	default:
+		t.Fatal("Close returned before Done was closed")
+	}
+}
+
+func TestRegistrationError(t *testing.T) {
+	fastSend(t)
+	srv := newTestServer(t)
+	srv.nick = ":srv 433 * bot :Nickname is already in use"
+	_, err := DialConfig(srv.ln.Addr().String(), Config{Nick: "bot", PingInterval: -1})
+	if !errors.Is(err, ErrNickInUse) {
+		t.Fatalf("got %v want ErrNickInUse", err)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	fastSend(t)
+	srv := newTestServer(t)
+	c, err := DialConfig(srv.ln.Addr().String(), Config{
+		Nick:         "bot",
+		PingInterval: 20 * time.Millisecond,
+		PingTimeout:  20 * time.Millisecond,
+		Reconnect:    ReconnectPolicy{MinDelay: time.Millisecond, Jitter: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range c.Msg {
+		}
+	}()
+
+	// the server does not answer our PINGs
+	expectEvent(t, c, DisconnectEvent{})
+	expectEvent(t, c, RetryEvent{})
+	if e := expectEvent(t, c, ReconnectEvent{}).(ReconnectEvent); e.Attempts != 1 {
+		t.Fatalf("got %#v", e)
+	}
+
+	// the server closes the connection
+	(<-srv.conns).Close()
+	(<-srv.conns).Close()
+	expectEvent(t, c, ReconnectEvent{})
+
+	if err := c.Close("bye"); err != nil {
+		t.Fatal(err)
+	}
+	<-c.Done
+}
+
+func TestNewClient(t *testing.T) {
+	fastSend(t)
+	for _, closeBy := range []string{"client", "server"} {
+		conn, server := net.Pipe()
+		srv := &testServer{lines: make(chan string, 100)}
+		go srv.serve(server)
+
+		// hide SetDeadline like a stdin/stdout pair would
+		c, err := NewClient(struct{ io.ReadWriteCloser }{conn}, Config{Nick: "bot", PingInterval: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.expect(t, "NICK bot")
+
+		if closeBy == "client" {
+			if err := c.Close("bye"); err != nil {
+				t.Fatal(err)
+			}
+			srv.expect(t, "QUIT :bye")
+			continue
+		}
+		go func() {
+			for range c.Msg {
+			}
+		}()
+		server.Close()
+		expectEvent(t, c, DisconnectEvent{})
+		select {
+		case <-c.Done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Client did not close without redial")
+		}
+		for range c.Events {
+		}
+		for i := 0; i < cap(c.send)+1; i++ {
+			if err := c.Send(Msg("#c", "hi")); err != ErrClosed {
+				t.Fatalf("Send after Done got %v", err)
+			}
+		}
+		if err := c.Close("bye"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChannelCharsets(t *testing.T) {
+	fastSend(t)
+	conn, server := net.Pipe()
+	srv := &testServer{lines: make(chan string, 100)}
+	go srv.serve(server)
+	c, err := NewClient(conn, Config{
+		Nick:            "bot",
+		PingInterval:    -1,
+		ChannelCharsets: map[string]Charset{"#Legacy": Latin9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close("bye")
+
+	io.WriteString(server, ":n!u@h PRIVMSG #legacy :\xa4\r\n")
+	if m := <-c.Msg; m.Trailing != "€" {
+		t.Fatalf("got %q want €", m.Trailing)
+	}
+	if err := c.Say("#LEGACY", "€"); err != nil {
+		t.Fatal(err)
+	}
+	srv.expect(t, "PRIVMSG #LEGACY :\xa4")
+}
+
+func TestEcho(t *testing.T) {
+	fastSend(t)
+	for _, suppress := range []bool{false, true} {
+		srv := newTestServer(t)
+		srv.caps = "echo-message labeled-response"
+		c, err := DialConfig(srv.ln.Addr().String(), Config{Nick: "bot", PingInterval: -1, SuppressEcho: suppress})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Send(Msg("#c", "hi")); err != nil {
+			t.Fatal(err)
+		}
+		if !suppress {
+			if m := <-c.Msg; !m.Self || m.Trailing != "hi" {
+				t.Fatalf("got %q self %v want our echo", m, m.Self)
+			}
+		}
+		if m := <-c.Msg; m.Self || m.Trailing != "re hi" {
+			t.Fatalf("got %q self %v", m, m.Self)
+		}
+
+		// the echo answers SendAndWait and is not passed on
+		ms, err := c.SendAndWait(context.Background(), Msg("#c", "wait"))
+		if err != nil || len(ms) != 1 || !ms[0].Self {
+			t.Fatalf("got response %v %v", ms, err)
+		}
+		if m := <-c.Msg; m.Trailing != "re wait" {
+			t.Fatalf("got %q want the reply", m)
+		}
+		c.Close("bye")
+	}
+}
+
+func TestSendAndWaitHandled(t *testing.T) {
+	fastSend(t)
+	srv := newTestServer(t)
+	srv.caps = "batch labeled-response"
+	c, err := DialConfig(srv.ln.Addr().String(), Config{Nick: "bot", PingInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close("bye")
+	cm := NewCM(c)
+	c.Handle(cm)
+
+	ms, err := c.SendAndWait(context.Background(), Join("#c"))
+	if err != nil || len(ms) != 3 {
+		t.Fatalf("got response %v %v", ms, err)
+	}
+	// the ChannelManager passes the BATCH on after tracking it
+	if m := <-c.Msg; m.Batch == nil {
+		t.Fatalf("got %q want the labeled BATCH", m)
+	}
+	if l := cm.List(); len(l) != 1 || l[0].Name() != "#c" || len(l[0].Names()) != 2 {
+		t.Fatalf("got channels %v", l)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	fastSend(t)
+	srv := newTestServerOn(t, "unix", t.TempDir()+"/irc.sock")
+	c, err := DialConfig("unix:"+srv.ln.Addr().String(), Config{Nick: "bot", PingInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close("bye"); err != nil {
+		t.Fatal(err)
+	}
+	srv.expect(t, "QUIT :bye")
+}
+
+// proxy runs serve for the first connection to a new listener
+func proxy(t *testing.T, serve func(net.Conn)) string {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+	}()
+	return ln.Addr().String()
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	got := make(chan []byte, 3)
+	addr := proxy(t, func(conn net.Conn) {
+		buf := make([]byte, 64)
+		for _, reply := range [][]byte{{5, 2}, {1, 0}, {5, 0, 0, 1, 127, 0, 0, 1, 0, 1}} {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			got <- append([]byte(nil), buf[:n]...)
+			conn.Write(reply)
+		}
+		io.WriteString(conn, ":srv NOTICE * :hi\r\n")
+	})
+
+	d := &SOCKS5Dialer{Address: addr, Username: "u", Password: "pw"}
+	conn, err := d.DialContext(context.Background(), "tcp", "irc.example.com:6697")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, want := range []string{
+		"\x05\x02\x00\x02",
+		"\x01\x01u\x02pw",
+		"\x05\x01\x00\x03\x0firc.example.com\x1a\x29",
+	} {
+		if b := <-got; string(b) != want {
+			t.Fatalf("got %q want %q", b, want)
+		}
+	}
+	if m, err := NewDecoder(conn).Decode(); err != nil || m.Trailing != "hi" {
+		t.Fatalf("got %v, %v", m, err)
+	}
+
+	addr = proxy(t, func(conn net.Conn) {
+		conn.Read(make([]byte, 64))
+		conn.Write([]byte{5, 0})
+		conn.Read(make([]byte, 64))
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+	})
+	d = &SOCKS5Dialer{Address: addr}
+	if _, err := d.DialContext(context.Background(), "tcp", "10.0.0.1:6667"); err == nil || err.Error() != "socks5: connection refused" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestHTTPConnectDialer(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	addr := proxy(t, func(conn net.Conn) {
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		got <- req
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n:srv NOTICE * :hi\r\n")
+	})
+
+	d := &HTTPConnectDialer{Address: addr, Username: "u", Password: "pw"}
+	conn, err := d.DialContext(context.Background(), "tcp", "irc.example.com:6697")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	req := <-got
+	if req.Method != "CONNECT" || req.Host != "irc.example.com:6697" || req.Header.Get("Proxy-Authorization") != "Basic dTpwdw==" {
+		t.Fatalf("got %s %s %v", req.Method, req.Host, req.Header)
+	}
+	// the NOTICE was read together with the response
+	if m, err := NewDecoder(conn).Decode(); err != nil || m.Trailing != "hi" {
+		t.Fatalf("got %v, %v", m, err)
+	}
+
+	addr = proxy(t, func(conn net.Conn) {
+		http.ReadRequest(bufio.NewReader(conn))
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n")
+	})
+	d = &HTTPConnectDialer{Address: addr}
+	if _, err := d.DialContext(context.Background(), "tcp", "irc.example.com:6697"); err == nil || !strings.Contains(err.Error(), "407") {
+		t.Fatalf("got %v", err)
+	}
+}
+
 func TestServerMessage(t *testing.T) {
 	test := tests["server"]
 	msg, err := ParseMessage(test.raw)
@@ -82,6 +1218,39 @@ func TestUserMessage(t *testing.T) {
 	}
 }
 
+func TestParser(t *testing.T) {
+	var p Parser
+	for _, name := range []string{"server", "user"} {
+		test := tests[name]
+		msg, err := p.Parse(test.raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.eqMsg(msg.Clone()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := p.Parse([]byte("@a=b\\sc;d :n!u@h CMD p1  p2 :tr ail \r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tags["a"] != "b c" || m.Parms.String() != "p1 p2 " || m.Trailing != "tr ail " {
+		t.Fatalf("got %#v", m)
+	}
+
+	// the tags of the last line must not survive the reuse of the buffer
+	p.Parse([]byte("@long-key=value;other=x CMD"))
+	if m, _ := p.Parse([]byte("@zz=1 OTHERCMD")); len(m.Tags) != 1 || m.Tags["zz"] != "1" {
+		t.Fatalf("got tags %q", m.Tags)
+	}
+
+	raw := tests["user"].raw
+	if n := testing.AllocsPerRun(100, func() { p.Parse(raw) }); n != 0 {
+		t.Fatalf("Parse allocates %v times", n)
+	}
+}
+
 func BenchmarkServerMessageParse(b *testing.B) {
 	test := tests["server"].raw
 	b.SetBytes(int64(len(test)))
@@ -123,6 +1292,45 @@ func BenchmarkUserMessageParse(b *testing.B) {
 	}
 }
 
+func BenchmarkParserServerMessage(b *testing.B) {
+	test := tests["server"].raw
+	b.SetBytes(int64(len(test)))
+	b.ReportAllocs()
+	var p Parser
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Parse(test)
+	}
+}
+
+func BenchmarkParserUserMessage(b *testing.B) {
+	test := tests["user"].raw
+	b.SetBytes(int64(len(test)))
+	b.ReportAllocs()
+	var p Parser
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Parse(test)
+	}
+}
+
+func BenchmarkParserUserMessagePooled(b *testing.B) {
+	test := tests["user"].raw
+	b.SetBytes(int64(len(test)))
+	b.ReportAllocs()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			p := GetParser()
+			p.Parse(test)
+			PutParser(p)
+		}
+	})
+}
+
 func BenchmarkUserMessageString(b *testing.B) {
 	test := tests["user"]
 	b.SetBytes(int64(len(test.raw)))
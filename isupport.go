@@ -0,0 +1,83 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ISupport holds the tokens the server advertises with RPL_ISUPPORT (005),
+// a token without value maps to ""
+type ISupport map[string]string
+
+// Int returns the numeric value of the token name or 0
+func (is ISupport) Int(name string) int {
+	n, _ := strconv.Atoi(is[name])
+	return n
+}
+
+// TargMax returns the maximal number of targets of command from the TARGMAX
+// token or 0 if there is no limit
+func (is ISupport) TargMax(command string) int {
+	for _, t := range strings.Split(is["TARGMAX"], ",") {
+		kv := strings.SplitN(t, ":", 2)
+		if len(kv) == 2 && strings.EqualFold(kv[0], command) {
+			n, _ := strconv.Atoi(kv[1])
+			return n
+		}
+	}
+	return 0
+}
+
+// Modes returns the maximal number of modes with a parameter in one MODE
+// command, 3 if the server does not advertise MODES and 0 if there is no limit
+func (is ISupport) Modes() int {
+	v, ok := is["MODES"]
+	if !ok {
+		return 3
+	}
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// update adds the tokens "NAME", "NAME=value" and removes "-NAME"
+func (is ISupport) update(tokens []string) {
+	for _, t := range tokens {
+		if strings.HasPrefix(t, "-") {
+			delete(is, t[1:])
+			continue
+		}
+		kv := strings.SplitN(t, "=", 2)
+		kv = append(kv, "")
+		is[kv[0]] = unescapeISupport(kv[1])
+	}
+}
+
+// unescapeISupport replaces the "\xHH" escapes in a value
+func unescapeISupport(v string) string {
+	if !strings.Contains(v, `\x`) {
+		return v
+	}
+	var b []byte
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+3 < len(v) && v[i+1] == 'x' {
+			if n, err := strconv.ParseUint(v[i+2:i+4], 16, 8); err == nil {
+				b = append(b, byte(n))
+				i += 3
+				continue
+			}
+		}
+		b = append(b, v[i])
+	}
+	return string(b)
+}
+
+// ISupport returns a copy of the RPL_ISUPPORT tokens of the server
+func (c *Client) ISupport() ISupport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	is := make(ISupport, len(c.isupport))
+	for k, v := range c.isupport {
+		is[k] = v
+	}
+	return is
+}
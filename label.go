@@ -0,0 +1,100 @@
+package irc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// ErrNoLabeledResponse is returned by SendAndWait if the server does not
+// support the labeled-response capability
+var ErrNoLabeledResponse = errors.New("labeled-response capability not enabled")
+
+// label adds a new label tag to m if labeled-response is enabled and m has none
+func (c *Client) label(m Message) Message {
+	if _, ok := m.Tags["label"]; ok || !c.CapEnabled("labeled-response") {
+		return m
+	}
+	c.mu.Lock()
+	c.labelID++
+	label := "l" + strconv.Itoa(c.labelID)
+	c.mu.Unlock()
+
+	tags := Tags{"label": label}
+	for k, v := range m.Tags {
+		tags[k] = v
+	}
+	m.Tags = tags
+	return m
+}
+
+// SendAndWait sends m and waits for the labeled response of the server. The
+// response of an ACK is empty, a labeled-response BATCH returns all Messages
+// in it. If the response contains an error reply or a FAIL its *ServerError or
+// *StandardReply is returned together with the Messages. The response is
+// passed to the Handler as well, only our echo is not.
+func (c *Client) SendAndWait(ctx context.Context, m Message) ([]Message, error) {
+	if !c.CapEnabled("labeled-response") {
+		return nil, ErrNoLabeledResponse
+	}
+	m = c.label(m)
+	label := m.Tags["label"]
+	res := make(chan []Message, 1)
+
+	c.mu.Lock()
+	c.labels[label] = res
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.labels, label)
+		c.mu.Unlock()
+	}()
+
+	if err := c.Send(m); err != nil {
+		return nil, err
+	}
+	select {
+	case ms := <-res:
+		return ms, responseErr(ms)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.Done:
+		return nil, ErrClosed
+	}
+}
+
+// deliverLabeled passes m to the SendAndWait waiting for its label and
+// reports if there was one
+func (c *Client) deliverLabeled(m Message) bool {
+	label, ok := m.Tags["label"]
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	res, ok := c.labels[label]
+	delete(c.labels, label)
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	switch {
+	case m.Command == "ACK":
+		res <- nil
+	case m.Batch != nil && m.Batch.Type == "labeled-response":
+		res <- m.Batch.Messages
+	default:
+		res <- []Message{m}
+	}
+	return true
+}
+
+// responseErr returns the error of the first error reply or FAIL in ms
+func responseErr(ms []Message) error {
+	for _, m := range ms {
+		if err := m.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
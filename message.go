@@ -1,8 +1,9 @@
 package irc
 
 import (
-	"errors"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Prefix represents an IRC prefix "Nick!User@Host"
@@ -16,7 +17,14 @@ func (p Prefix) String() string {
 	if p.Nick == "" && p.User == "" {
 		return p.Host
 	}
-	return p.Nick + "!" + p.User + "@" + p.Host
+	str := p.Nick
+	if p.User != "" {
+		str += "!" + p.User
+	}
+	if p.Host != "" {
+		str += "@" + p.Host
+	}
+	return str
 }
 
 // ParsePrefix parses the string into a new Prefix. Name and User will be empty if
@@ -46,60 +54,129 @@ func (p Parms) String() string {
 	return str
 }
 
+// Tags are the IRCv3 message tags of a Message, a tag without value maps to ""
+type Tags map[string]string
+
+var (
+	tagEscaper   = strings.NewReplacer("\\", "\\\\", ";", "\\:", " ", "\\s", "\r", "\\r", "\n", "\\n")
+	tagUnescaper = strings.NewReplacer("\\\\", "\\", "\\:", ";", "\\s", " ", "\\r", "\r", "\\n", "\n", "\\", "")
+)
+
+// String returns the Tags in the wire format "key=value;key2" without the leading "@"
+func (t Tags) String() string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var str string
+	for i, k := range keys {
+		if i > 0 {
+			str += ";"
+		}
+		str += k
+		if v := t[k]; v != "" {
+			str += "=" + tagEscaper.Replace(v)
+		}
+	}
+	return str
+}
+
+// ParseTags parses the wire format of tags without the leading "@". Later
+// occurrences of a key overwrite earlier ones.
+func ParseTags(tags string) Tags {
+	t := make(Tags)
+	for _, tag := range strings.Split(tags, ";") {
+		if tag == "" {
+			continue
+		}
+		kv := strings.SplitN(tag, "=", 2)
+		if len(kv) == 1 {
+			t[kv[0]] = ""
+			continue
+		}
+		t[kv[0]] = tagUnescaper.Replace(kv[1])
+	}
+	return t
+}
+
 // Message represents a IRC Message like it gets send over the TCP stream
 type Message struct {
+	Tags     Tags
 	Prefix   Prefix
 	Command  string
 	Parms    Parms
 	Trailing string
+	// HasTrailing marks a trailing parameter that is empty (" :")
+	HasTrailing bool
+
+	// Batch is set on the "BATCH +ref" Message of a received BATCH
+	Batch *Batch
+
+	// Time is the server-time of the Message or the time it was received
+	Time time.Time
+
+	// Self is set on our own PRIVMSG, NOTICE and TAGMSG echoed by the server
+	Self bool
+
+	// Raw holds the received line without "\r\n" before any decoding
+	Raw []byte
 }
 
-// String returns a string represantation of the Message and contains a terminating \r\n
+// String returns a string represantation of the Message and contains a terminating \r\n.
+// Trailing is written if it is not empty or HasTrailing is set, a last
+// parameter in Parms that needs to be a trailing one is written as such.
 func (m Message) String() string {
-	var tail string
-	if m.Trailing == "" {
-		tail = ""
-	} else {
-		tail = ":" + m.Trailing
+	var b strings.Builder
+	if len(m.Tags) > 0 {
+		b.WriteString("@")
+		b.WriteString(m.Tags.String())
+		b.WriteString(" ")
 	}
-	if m.Prefix.Host == "" {
-		return m.Command + " " + m.Parms.String() + tail + "\r\n"
+	if p := m.Prefix.String(); p != "" {
+		b.WriteString(":")
+		b.WriteString(p)
+		b.WriteString(" ")
 	}
-	return ":" + m.Prefix.String() + " " + m.Command + " " + m.Parms.String() + tail + "\r\n"
-}
-
-// ParseMessage parses the raw Message
-func ParseMessage(b []byte) (Message, error) {
-	var m Message
-	var tmp []string
-	str := string(b)
+	b.WriteString(m.Command)
 
-	if strings.HasPrefix(str, ":") {
-		tmp = strings.SplitN(str, " ", 2)
-		m.Prefix = ParsePrefix(tmp[0][1:])
-		str = tmp[1]
+	parms, trailing, hasTrailing := m.Parms, m.Trailing, m.HasTrailing || m.Trailing != ""
+	if n := len(parms); !hasTrailing && n > 0 && needsTrailing(parms[n-1]) {
+		parms, trailing, hasTrailing = parms[:n-1], parms[n-1], true
 	}
-
-	tmp = strings.SplitN(str, ":", 2)
-	if len(tmp) > 1 {
-		m.Trailing = strings.TrimSpace(tmp[1])
+	for _, p := range parms {
+		b.WriteString(" ")
+		b.WriteString(p)
 	}
-
-	tmp = strings.Fields(tmp[0])
-
-	l := len(tmp)
-	if l < 1 {
-		return m, errors.New("massage has no command")
+	if hasTrailing {
+		b.WriteString(" :")
+		b.WriteString(trailing)
 	}
+	b.WriteString("\r\n")
+	return b.String()
+}
 
-	m.Command = tmp[0]
-	if l == 1 {
-		return m, nil
+// needsTrailing reports if the parameter p can only be send as trailing parameter
+func needsTrailing(p string) bool {
+	return p == "" || p[0] == ':' || strings.Contains(p, " ")
+}
 
+// Params returns Parms and Trailing (if any) as one list
+func (m Message) Params() Parms {
+	if !m.HasTrailing && m.Trailing == "" {
+		return m.Parms
 	}
+	return append(m.Parms[:len(m.Parms):len(m.Parms)], m.Trailing)
+}
 
-	m.Parms = tmp[1:]
-	return m, nil
+// ParseMessage parses the raw Message following the RFC 1459 grammar. A
+// trailing parameter (":...") is stored in Trailing even if it is empty.
+func ParseMessage(b []byte) (Message, error) {
+	p := GetParser()
+	defer PutParser(p)
+	m, err := p.Parse(b)
+	return m.Clone(), err
 }
 
 // Op creates a MODE message to set "+o" to nick in channel
@@ -117,9 +194,10 @@ func Op(nick, channel string) Message {
 // Msg creates a PRIVMSG so recv (channel/nick) with the conntent of str
 func Msg(recv, str string) Message {
 	return Message{
-		Command:  "PRIVMSG",
-		Parms:    Parms{0: recv},
-		Trailing: str,
+		Command:     "PRIVMSG",
+		Parms:       Parms{0: recv},
+		Trailing:    str,
+		HasTrailing: true,
 	}
 }
 
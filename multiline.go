@@ -0,0 +1,109 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// multilineLimits returns the max-bytes and max-lines values of the
+// draft/multiline capability, 0 means no limit
+func multilineLimits(value string) (maxBytes, maxLines int) {
+	for _, kv := range strings.Split(value, ",") {
+		kv := strings.SplitN(kv, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		n, err := strconv.Atoi(kv[1])
+		if err != nil {
+			continue
+		}
+		switch kv[0] {
+		case "max-bytes":
+			maxBytes = n
+		case "max-lines":
+			maxLines = n
+		}
+	}
+	return maxBytes, maxLines
+}
+
+// Multiline sends the multi-line text as PRIVMSG to target. If the server
+// supports draft/multiline the lines are send as one or more BATCHes, long
+// lines are split and joined with the draft/multiline-concat tag. Only the
+// opening BATCH gets a label. Otherwise it falls back to Say.
+func (c *Client) Multiline(target, text string) error {
+	if !c.CapEnabled("draft/multiline") {
+		return c.Say(target, text)
+	}
+	if strings.Trim(text, "\r\n") == "" {
+		// a batch of blank lines is invalid
+		return nil
+	}
+	value, _ := c.CapValue("draft/multiline")
+	maxBytes, maxLines := multilineLimits(value)
+	max := c.textBudget("PRIVMSG", target)
+
+	var (
+		batch        []Message
+		bytes, lines int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		c.mu.Lock()
+		c.batchID++
+		ref := "ml" + strconv.Itoa(c.batchID)
+		c.mu.Unlock()
+
+		if err := c.Send(Message{
+			Command: "BATCH",
+			Parms:   Parms{"+" + ref, "draft/multiline", target},
+		}); err != nil {
+			return err
+		}
+		for _, m := range batch {
+			m.Tags["batch"] = ref
+			if err := c.enqueue(m); err != nil {
+				return err
+			}
+		}
+		batch, bytes, lines = nil, 0, 0
+		return c.enqueue(Message{
+			Command: "BATCH",
+			Parms:   Parms{"-" + ref},
+		})
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		for i, s := range splitText(line, max, true) {
+			n := len(s)
+			if len(batch) > 0 && i == 0 {
+				n += len("\n")
+			}
+			if (maxBytes > 0 && bytes+n > maxBytes) || (maxLines > 0 && lines+1 > maxLines) {
+				if err := flush(); err != nil {
+					return err
+				}
+				n = len(s)
+			}
+
+			m := Message{
+				Tags:        Tags{},
+				Command:     "PRIVMSG",
+				Parms:       Parms{target},
+				Trailing:    s,
+				HasTrailing: true,
+			}
+			// a BATCH must not start with a concatenated line
+			if i > 0 && len(batch) > 0 {
+				m.Tags["draft/multiline-concat"] = ""
+			}
+			batch = append(batch, m)
+			bytes += n
+			lines++
+		}
+	}
+	return flush()
+}
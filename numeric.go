@@ -0,0 +1,174 @@
+package irc
+
+// Numeric is the symbolic name and description of a numeric reply code
+type Numeric struct {
+	Code        string
+	Name        string // e.g. "RPL_WELCOME"
+	Description string // the reply format of the RFCs or the description
+}
+
+var numerics = map[string]Numeric{
+	RplWELCOME:           {RplWELCOME, "RPL_WELCOME", "Welcome to the Internet Relay Network <nick>!<user>@<host>"},
+	RplYOURHOST:          {RplYOURHOST, "RPL_YOURHOST", "Your host is <servername>, running version <ver>"},
+	RplCREATED:           {RplCREATED, "RPL_CREATED", "This server was created <date>"},
+	RplMYINFO:            {RplMYINFO, "RPL_MYINFO", "<servername> <version> <available user modes> <available channel modes>"},
+	RplBOUNCE:            {RplBOUNCE, "RPL_BOUNCE", "<hostname> <port> :<info>"},
+	RplISUPPORT:          {RplISUPPORT, "RPL_ISUPPORT", "<nick> <token>[=<value>] ... :are supported by this server"},
+	RplTRACELINK:         {RplTRACELINK, "RPL_TRACELINK", "Link <version & debug level> <destination> <next server> V<protocol version> <link uptime in seconds> <backstream sendq> <upstream sendq>"},
+	RplTRACECONNECTING:   {RplTRACECONNECTING, "RPL_TRACECONNECTING", "Try. <class> <server>"},
+	RplTRACEHANDSHAKE:    {RplTRACEHANDSHAKE, "RPL_TRACEHANDSHAKE", "H.S. <class> <server>"},
+	RplTRACEUNKNOWN:      {RplTRACEUNKNOWN, "RPL_TRACEUNKNOWN", "???? <class> [<client IP address in dot form>]"},
+	RplTRACEOPERATOR:     {RplTRACEOPERATOR, "RPL_TRACEOPERATOR", "Oper <class> <nick>"},
+	RplTRACEUSER:         {RplTRACEUSER, "RPL_TRACEUSER", "User <class> <nick>"},
+	RplTRACESERVER:       {RplTRACESERVER, "RPL_TRACESERVER", "Serv <class> <int>S <int>C <server> <nick!user|*!*>@<host|server> V<protocol version>"},
+	RplTRACESERVICE:      {RplTRACESERVICE, "RPL_TRACESERVICE", "Service <class> <name> <type> <active type>"},
+	RplTRACENEWTYPE:      {RplTRACENEWTYPE, "RPL_TRACENEWTYPE", "<newtype> 0 <client name>"},
+	RplTRACECLASS:        {RplTRACECLASS, "RPL_TRACECLASS", "Class <class> <count>"},
+	RplTRACERECONNECT:    {RplTRACERECONNECT, "RPL_TRACERECONNECT", "Unused."},
+	RplSTATSLINKINFO:     {RplSTATSLINKINFO, "RPL_STATSLINKINFO", "<linkname> <sendq> <sent messages> <sent Kbytes> <received messages> <received Kbytes> <time open>"},
+	RplSTATSCOMMANDS:     {RplSTATSCOMMANDS, "RPL_STATSCOMMANDS", "<command> <count> <byte count> <remote count>"},
+	RplENDOFSTATS:        {RplENDOFSTATS, "RPL_ENDOFSTATS", "<stats letter> :End of STATS report"},
+	RplUMODEIS:           {RplUMODEIS, "RPL_UMODEIS", "<user mode string>"},
+	RplSERVLIST:          {RplSERVLIST, "RPL_SERVLIST", "<name> <server> <mask> <type> <hopcount> <info>"},
+	RplSERVLISTEND:       {RplSERVLISTEND, "RPL_SERVLISTEND", "<mask> <type> :End of service listing"},
+	RplSTATSUPTIME:       {RplSTATSUPTIME, "RPL_STATSUPTIME", ":Server Up %d days %d:%02d:%02d"},
+	RplSTATSOLINE:        {RplSTATSOLINE, "RPL_STATSOLINE", "O <hostmask> * <name>"},
+	RplLUSERCLIENT:       {RplLUSERCLIENT, "RPL_LUSERCLIENT", ":There are <integer> users and <integer> services on <integer> servers"},
+	RplLUSEROP:           {RplLUSEROP, "RPL_LUSEROP", "<integer> :operator(s) online"},
+	RplLUSERUNKNOWN:      {RplLUSERUNKNOWN, "RPL_LUSERUNKNOWN", "<integer> :unknown connection(s)"},
+	RplLUSERCHANNELS:     {RplLUSERCHANNELS, "RPL_LUSERCHANNELS", "<integer> :channels formed"},
+	RplLUSERME:           {RplLUSERME, "RPL_LUSERME", ":I have <integer> clients and <integer> servers"},
+	RplADMINME:           {RplADMINME, "RPL_ADMINME", "<server> :Administrative info"},
+	RplADMINLOC1:         {RplADMINLOC1, "RPL_ADMINLOC1", ":<admin info>"},
+	RplADMINLOC2:         {RplADMINLOC2, "RPL_ADMINLOC2", ":<admin info>"},
+	RplADMINEMAIL:        {RplADMINEMAIL, "RPL_ADMINEMAIL", ":<admin info>"},
+	RplTRACELOG:          {RplTRACELOG, "RPL_TRACELOG", "File <logfile> <debug level>"},
+	RplTRACEEND:          {RplTRACEEND, "RPL_TRACEEND", "<server name> <version & debug level> :End of TRACE"},
+	RplTRYAGAIN:          {RplTRYAGAIN, "RPL_TRYAGAIN", "<command> :Please wait a while and try again."},
+	RplAWAY:              {RplAWAY, "RPL_AWAY", "<nick> :<away message>"},
+	RplUSERHOST:          {RplUSERHOST, "RPL_USERHOST", ":*1<reply> *( \" \" <reply> )"},
+	RplISON:              {RplISON, "RPL_ISON", ":*1<nick> *( \" \" <nick> )"},
+	RplUNAWAY:            {RplUNAWAY, "RPL_UNAWAY", ":You are no longer marked as being away"},
+	RplNOWAWAY:           {RplNOWAWAY, "RPL_NOWAWAY", ":You have been marked as being away"},
+	RplWHOISUSER:         {RplWHOISUSER, "RPL_WHOISUSER", "<nick> <user> <host> * :<real name>"},
+	RplWHOISSERVER:       {RplWHOISSERVER, "RPL_WHOISSERVER", "<nick> <server> :<server info>"},
+	RplWHOISOPERATOR:     {RplWHOISOPERATOR, "RPL_WHOISOPERATOR", "<nick> :is an IRC operator"},
+	RplWHOWASUSER:        {RplWHOWASUSER, "RPL_WHOWASUSER", "<nick> <user> <host> * :<real name>"},
+	RplENDOFWHO:          {RplENDOFWHO, "RPL_ENDOFWHO", "<name> :End of WHO list"},
+	RplWHOISIDLE:         {RplWHOISIDLE, "RPL_WHOISIDLE", "<nick> <integer> :seconds idle"},
+	RplENDOFWHOIS:        {RplENDOFWHOIS, "RPL_ENDOFWHOIS", "<nick> :End of WHOIS list"},
+	RplWHOISCHANNELS:     {RplWHOISCHANNELS, "RPL_WHOISCHANNELS", "<nick> :*( ( \"@\" / \"+\" ) <channel> \" \" )"},
+	RplLISTSTART:         {RplLISTSTART, "RPL_LISTSTART", "Obsolete."},
+	RplLIST:              {RplLIST, "RPL_LIST", "<channel> <# visible> :<topic>"},
+	RplLISTEND:           {RplLISTEND, "RPL_LISTEND", ":End of LIST"},
+	RplCHANNELMODEIS:     {RplCHANNELMODEIS, "RPL_CHANNELMODEIS", "<channel> <mode> <mode params>"},
+	RplUNIQOPIS:          {RplUNIQOPIS, "RPL_UNIQOPIS", "<channel> <nickname>"},
+	RplNOTOPIC:           {RplNOTOPIC, "RPL_NOTOPIC", "<channel> :No topic is set"},
+	RplTOPIC:             {RplTOPIC, "RPL_TOPIC", "<channel> :<topic>"},
+	RplINVITING:          {RplINVITING, "RPL_INVITING", "<channel> <nick>"},
+	RplSUMMONING:         {RplSUMMONING, "RPL_SUMMONING", "<user> :Summoning user to IRC"},
+	RplINVITELIST:        {RplINVITELIST, "RPL_INVITELIST", "<channel> <invitemask>"},
+	RplENDOFINVITELIST:   {RplENDOFINVITELIST, "RPL_ENDOFINVITELIST", "<channel> :End of channel invite list"},
+	RplEXCEPTLIST:        {RplEXCEPTLIST, "RPL_EXCEPTLIST", "<channel> <exceptionmask>"},
+	RplENDOFEXCEPTLIST:   {RplENDOFEXCEPTLIST, "RPL_ENDOFEXCEPTLIST", "<channel> :End of channel exception list"},
+	RplVERSION:           {RplVERSION, "RPL_VERSION", "<version>.<debuglevel> <server> :<comments>"},
+	RplWHOREPLY:          {RplWHOREPLY, "RPL_WHOREPLY", "<channel> <user> <host> <server> <nick> ( \"H\" / \"G\" > [\"*\"] [ ( \"@\" / \"+\" ) ] :<hopcount> <real name>"},
+	RplNAMREPLY:          {RplNAMREPLY, "RPL_NAMREPLY", "( \"=\" / \"*\" / \"@\" ) <channel> :[ \"@\" / \"+\" ] <nick> *( \" \" [ \"@\" / \"+\" ] <nick> )"},
+	RplLINKS:             {RplLINKS, "RPL_LINKS", "<mask> <server> :<hopcount> <server info>"},
+	RplENDOFLINKS:        {RplENDOFLINKS, "RPL_ENDOFLINKS", "<mask> :End of LINKS list"},
+	RplENDOFNAMES:        {RplENDOFNAMES, "RPL_ENDOFNAMES", "<channel> :End of NAMES list"},
+	RplBANLIST:           {RplBANLIST, "RPL_BANLIST", "<channel> <banmask>"},
+	RplENDOFBANLIST:      {RplENDOFBANLIST, "RPL_ENDOFBANLIST", "<channel> :End of channel ban list"},
+	RplENDOFWHOWAS:       {RplENDOFWHOWAS, "RPL_ENDOFWHOWAS", "<nick> :End of WHOWAS"},
+	RplINFO:              {RplINFO, "RPL_INFO", ":<string>"},
+	RplMOTD:              {RplMOTD, "RPL_MOTD", ":- <text>"},
+	RplENDOFINFO:         {RplENDOFINFO, "RPL_ENDOFINFO", ":End of INFO list"},
+	RplMOTDSTART:         {RplMOTDSTART, "RPL_MOTDSTART", ":- <server> Message of the day - "},
+	RplENDOFMOTD:         {RplENDOFMOTD, "RPL_ENDOFMOTD", ":End of MOTD command"},
+	RplYOUREOPER:         {RplYOUREOPER, "RPL_YOUREOPER", ":You are now an IRC operator"},
+	RplREHASHING:         {RplREHASHING, "RPL_REHASHING", "<config file> :Rehashing"},
+	RplYOURESERVICE:      {RplYOURESERVICE, "RPL_YOURESERVICE", "You are service <servicename>"},
+	RplTIME:              {RplTIME, "RPL_TIME", "<server> :<string showing server's local time>"},
+	RplUSERSSTART:        {RplUSERSSTART, "RPL_USERSSTART", ":UserID Terminal Host"},
+	RplUSERS:             {RplUSERS, "RPL_USERS", ":<username> <ttyline> <hostname>"},
+	RplENDOFUSERS:        {RplENDOFUSERS, "RPL_ENDOFUSERS", ":End of users"},
+	RplNOUSERS:           {RplNOUSERS, "RPL_NOUSERS", ":Nobody logged in"},
+	RplCREATIONTIME:      {RplCREATIONTIME, "RPL_CREATIONTIME", "<channel> <creationtime>"},
+	RplTOPICWHOTIME:      {RplTOPICWHOTIME, "RPL_TOPICWHOTIME", "<channel> <nick> <setat>"},
+	RplVISIBLEHOST:       {RplVISIBLEHOST, "RPL_VISIBLEHOST", "<nick> <host> :is now your displayed host"},
+	RplSTARTTLS:          {RplSTARTTLS, "RPL_STARTTLS", ":STARTTLS successful, proceed with TLS handshake"},
+	ErrSTARTTLS:          {ErrSTARTTLS, "ERR_STARTTLS", ":STARTTLS failed (Wrong moon phase)"},
+	RplMONONLINE:         {RplMONONLINE, "RPL_MONONLINE", "<nick> :<target>[!<user>@<host>]{,<target>[!<user>@<host>]}"},
+	RplMONOFFLINE:        {RplMONOFFLINE, "RPL_MONOFFLINE", "<nick> :<target>{,<target>}"},
+	RplMONLIST:           {RplMONLIST, "RPL_MONLIST", "<nick> :<target>{,<target>}"},
+	RplENDOFMONLIST:      {RplENDOFMONLIST, "RPL_ENDOFMONLIST", "<nick> :End of MONITOR list"},
+	ErrMONLISTFULL:       {ErrMONLISTFULL, "ERR_MONLISTFULL", "<nick> <limit> <targets> :Monitor list is full."},
+	RplLOGGEDIN:          {RplLOGGEDIN, "RPL_LOGGEDIN", "<nick> <nick>!<user>@<host> <account> :You are now logged in as <account>"},
+	RplLOGGEDOUT:         {RplLOGGEDOUT, "RPL_LOGGEDOUT", "<nick> <nick>!<user>@<host> :You are now logged out"},
+	ErrNICKLOCKED:        {ErrNICKLOCKED, "ERR_NICKLOCKED", "<nick> :You must use a nick assigned to you"},
+	RplSASLSUCCESS:       {RplSASLSUCCESS, "RPL_SASLSUCCESS", "<nick> :SASL authentication successful"},
+	ErrSASLFAIL:          {ErrSASLFAIL, "ERR_SASLFAIL", "<nick> :SASL authentication failed"},
+	ErrSASLTOOLONG:       {ErrSASLTOOLONG, "ERR_SASLTOOLONG", "<nick> :SASL message too long"},
+	ErrSASLABORTED:       {ErrSASLABORTED, "ERR_SASLABORTED", "<nick> :SASL authentication aborted"},
+	ErrSASLALREADY:       {ErrSASLALREADY, "ERR_SASLALREADY", "<nick> :You have already authenticated using SASL"},
+	RplSASLMECHS:         {RplSASLMECHS, "RPL_SASLMECHS", "<nick> <mechanisms> :are available SASL mechanisms"},
+	ErrNOSUCHNICK:        {ErrNOSUCHNICK, "ERR_NOSUCHNICK", "<nickname> :No such nick/channel"},
+	ErrNOSUCHSERVER:      {ErrNOSUCHSERVER, "ERR_NOSUCHSERVER", "<server name> :No such server"},
+	ErrNOSUCHCHANNEL:     {ErrNOSUCHCHANNEL, "ERR_NOSUCHCHANNEL", "<channel name> :No such channel"},
+	ErrCANNOTSENDTOCHAN:  {ErrCANNOTSENDTOCHAN, "ERR_CANNOTSENDTOCHAN", "<channel name> :Cannot send to channel"},
+	ErrTOOMANYCHANNELS:   {ErrTOOMANYCHANNELS, "ERR_TOOMANYCHANNELS", "<channel name> :You have joined too many channels"},
+	ErrWASNOSUCHNICK:     {ErrWASNOSUCHNICK, "ERR_WASNOSUCHNICK", "<nickname> :There was no such nickname"},
+	ErrTOOMANYTARGETS:    {ErrTOOMANYTARGETS, "ERR_TOOMANYTARGETS", "<target> :<error code> recipients. <abort message>"},
+	ErrNOSUCHSERVICE:     {ErrNOSUCHSERVICE, "ERR_NOSUCHSERVICE", "<service name> :No such service"},
+	ErrNOORIGIN:          {ErrNOORIGIN, "ERR_NOORIGIN", ":No origin specified"},
+	ErrNORECIPIENT:       {ErrNORECIPIENT, "ERR_NORECIPIENT", ":No recipient given (<command>)"},
+	ErrNOTEXTTOSEND:      {ErrNOTEXTTOSEND, "ERR_NOTEXTTOSEND", ":No text to send"},
+	ErrNOTOPLEVEL:        {ErrNOTOPLEVEL, "ERR_NOTOPLEVEL", "<mask> :No toplevel domain specified"},
+	ErrWILDTOPLEVEL:      {ErrWILDTOPLEVEL, "ERR_WILDTOPLEVEL", "<mask> :Wildcard in toplevel domain"},
+	ErrBADMASK:           {ErrBADMASK, "ERR_BADMASK", "<mask> :Bad Server/host mask"},
+	ErrUNKNOWNCOMMAND:    {ErrUNKNOWNCOMMAND, "ERR_UNKNOWNCOMMAND", "<command> :Unknown command"},
+	ErrNOMOTD:            {ErrNOMOTD, "ERR_NOMOTD", ":MOTD File is missing"},
+	ErrNOADMININFO:       {ErrNOADMININFO, "ERR_NOADMININFO", "<server> :No administrative info available"},
+	ErrFILEERROR:         {ErrFILEERROR, "ERR_FILEERROR", ":File error doing <file op> on <file>"},
+	ErrNONICKNAMEGIVEN:   {ErrNONICKNAMEGIVEN, "ERR_NONICKNAMEGIVEN", ":No nickname given"},
+	ErrERRONEUSNICKNAME:  {ErrERRONEUSNICKNAME, "ERR_ERRONEUSNICKNAME", "<nick> :Erroneous nickname"},
+	ErrNICKNAMEINUSE:     {ErrNICKNAMEINUSE, "ERR_NICKNAMEINUSE", "<nick> :Nickname is already in use"},
+	ErrNICKCOLLISION:     {ErrNICKCOLLISION, "ERR_NICKCOLLISION", "<nick> :Nickname collision KILL from <user>@<host>"},
+	ErrUNAVAILRESOURCE:   {ErrUNAVAILRESOURCE, "ERR_UNAVAILRESOURCE", "<nick/channel> :Nick/channel is temporarily unavailable"},
+	ErrUSERNOTINCHANNEL:  {ErrUSERNOTINCHANNEL, "ERR_USERNOTINCHANNEL", "<nick> <channel> :They aren't on that channel"},
+	ErrNOTONCHANNEL:      {ErrNOTONCHANNEL, "ERR_NOTONCHANNEL", "<channel> :You're not on that channel"},
+	ErrUSERONCHANNEL:     {ErrUSERONCHANNEL, "ERR_USERONCHANNEL", "<user> <channel> :is already on channel"},
+	ErrNOLOGIN:           {ErrNOLOGIN, "ERR_NOLOGIN", "<user> :User not logged in"},
+	ErrSUMMONDISABLED:    {ErrSUMMONDISABLED, "ERR_SUMMONDISABLED", ":SUMMON has been disabled"},
+	ErrUSERSDISABLED:     {ErrUSERSDISABLED, "ERR_USERSDISABLED", ":USERS has been disabled"},
+	ErrNOTREGISTERED:     {ErrNOTREGISTERED, "ERR_NOTREGISTERED", ":You have not registered"},
+	ErrNEEDMOREPARAMS:    {ErrNEEDMOREPARAMS, "ERR_NEEDMOREPARAMS", "<command> :Not enough parameters"},
+	ErrALREADYREGISTRED:  {ErrALREADYREGISTRED, "ERR_ALREADYREGISTRED", ":Unauthorized command (already registered)"},
+	ErrNOPERMFORHOST:     {ErrNOPERMFORHOST, "ERR_NOPERMFORHOST", ":Your host isn't among the privileged"},
+	ErrPASSWDMISMATCH:    {ErrPASSWDMISMATCH, "ERR_PASSWDMISMATCH", ":Password incorrect"},
+	ErrYOUREBANNEDCREEP:  {ErrYOUREBANNEDCREEP, "ERR_YOUREBANNEDCREEP", ":You are banned from this server"},
+	ErrYOUWILLBEBANNED:   {ErrYOUWILLBEBANNED, "ERR_YOUWILLBEBANNED", ":You will be banned from this server"},
+	ErrKEYSET:            {ErrKEYSET, "ERR_KEYSET", "<channel> :Channel key already set"},
+	ErrCHANNELISFULL:     {ErrCHANNELISFULL, "ERR_CHANNELISFULL", "<channel> :Cannot join channel (+l)"},
+	ErrUNKNOWNMODE:       {ErrUNKNOWNMODE, "ERR_UNKNOWNMODE", "<char> :is unknown mode char to me for <channel>"},
+	ErrINVITEONLYCHAN:    {ErrINVITEONLYCHAN, "ERR_INVITEONLYCHAN", "<channel> :Cannot join channel (+i)"},
+	ErrBANNEDFROMCHAN:    {ErrBANNEDFROMCHAN, "ERR_BANNEDFROMCHAN", "<channel> :Cannot join channel (+b)"},
+	ErrBADCHANNELKEY:     {ErrBADCHANNELKEY, "ERR_BADCHANNELKEY", "<channel> :Cannot join channel (+k)"},
+	ErrBADCHANMASK:       {ErrBADCHANMASK, "ERR_BADCHANMASK", "<channel> :Bad Channel Mask"},
+	ErrNOCHANMODES:       {ErrNOCHANMODES, "ERR_NOCHANMODES", "<channel> :Channel doesn't support modes"},
+	ErrBANLISTFULL:       {ErrBANLISTFULL, "ERR_BANLISTFULL", "<channel> <char> :Channel list is full"},
+	ErrNOPRIVILEGES:      {ErrNOPRIVILEGES, "ERR_NOPRIVILEGES", ":Permission Denied- You're not an IRC operator"},
+	ErrCHANOPRIVSNEEDED:  {ErrCHANOPRIVSNEEDED, "ERR_CHANOPRIVSNEEDED", "<channel> :You're not channel operator"},
+	ErrCANTKILLSERVER:    {ErrCANTKILLSERVER, "ERR_CANTKILLSERVER", ":You can't kill a server!"},
+	ErrRESTRICTED:        {ErrRESTRICTED, "ERR_RESTRICTED", ":Your connection is restricted!"},
+	ErrUNIQOPPRIVSNEEDED: {ErrUNIQOPPRIVSNEEDED, "ERR_UNIQOPPRIVSNEEDED", ":You're not the original channel operator"},
+	ErrNOOPERHOST:        {ErrNOOPERHOST, "ERR_NOOPERHOST", ":No O-lines for your host"},
+	ErrUMODEUNKNOWNFLAG:  {ErrUMODEUNKNOWNFLAG, "ERR_UMODEUNKNOWNFLAG", ":Unknown MODE flag"},
+	ErrUSERSDONTMATCH:    {ErrUSERSDONTMATCH, "ERR_USERSDONTMATCH", ":Cannot change mode for other users"},
+}
+
+// LookupNumeric returns the Numeric of code, e.g. "001" or "433"
+func LookupNumeric(code string) (Numeric, bool) {
+	n, ok := numerics[code]
+	return n, ok
+}
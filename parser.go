@@ -0,0 +1,215 @@
+package irc
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// maxParms is the maximal number of parameters of a Message
+const maxParms = 15
+
+// ErrNoCommand is returned for lines without a command
+var ErrNoCommand = errors.New("massage has no command")
+
+var parserPool = sync.Pool{
+	New: func() interface{} {
+		return new(Parser)
+	},
+}
+
+// GetParser returns a Parser from the pool
+func GetParser() *Parser {
+	return parserPool.Get().(*Parser)
+}
+
+// PutParser puts p back into the pool, the last Message of p must not be
+// used afterwards
+func PutParser(p *Parser) {
+	parserPool.Put(p)
+}
+
+// Parser parses lines into Messages in a single pass over the bytes. Once its
+// buffers have grown it does not allocate. The strings of the Message returned
+// by Parse reference the buffer of the Parser and are only valid till the
+// next call of Parse, use Message.Clone to keep them.
+type Parser struct {
+	buf   []byte
+	parms [maxParms]string
+	tags  Tags
+	msg   Message
+}
+
+// bstr returns a string referencing b without copying it
+func bstr(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
+
+// Parse parses the line b with or without the terminating "\r\n"
+func (p *Parser) Parse(b []byte) (*Message, error) {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	// the keys of the last tags reference buf, remove them before it changes
+	for k := range p.tags {
+		delete(p.tags, k)
+	}
+	p.buf = append(p.buf[:0], b...)
+	b = p.buf
+	p.msg = Message{}
+	m := &p.msg
+
+	// @tags
+	if len(b) > 0 && b[0] == '@' {
+		end := indexSpace(b)
+		m.Tags = p.parseTags(b[1:end])
+		b = skipSpaces(b[end:])
+	}
+
+	// :prefix
+	if len(b) > 0 && b[0] == ':' {
+		end := indexSpace(b)
+		m.Prefix = ParsePrefix(bstr(b[1:end]))
+		b = skipSpaces(b[end:])
+	}
+
+	// command
+	end := indexSpace(b)
+	if end == 0 {
+		return m, ErrNoCommand
+	}
+	m.Command = bstr(b[:end])
+	b = skipSpaces(b[end:])
+
+	// parameters, the last parameter may contain spaces if it starts with ":"
+	n := 0
+	for len(b) > 0 {
+		if b[0] == ':' || n == maxParms-1 {
+			if b[0] == ':' {
+				b = b[1:]
+			}
+			m.Trailing = bstr(b)
+			m.HasTrailing = true
+			break
+		}
+		end := indexSpace(b)
+		p.parms[n] = bstr(b[:end])
+		n++
+		b = skipSpaces(b[end:])
+	}
+	if n > 0 {
+		m.Parms = Parms(p.parms[:n:n])
+	}
+
+	if t, ok := m.Tags["time"]; ok {
+		m.Time, _ = time.Parse(time.RFC3339Nano, t)
+	}
+	return m, nil
+}
+
+// parseTags parses "key=value;key2" and unescapes the values in place
+func (p *Parser) parseTags(b []byte) Tags {
+	if p.tags == nil {
+		p.tags = make(Tags)
+	}
+	for len(b) > 0 {
+		end := 0
+		for end < len(b) && b[end] != ';' {
+			end++
+		}
+		tag := b[:end]
+		if end < len(b) {
+			end++
+		}
+		b = b[end:]
+		if len(tag) == 0 {
+			continue
+		}
+
+		eq := 0
+		for eq < len(tag) && tag[eq] != '=' {
+			eq++
+		}
+		if eq == len(tag) {
+			p.tags[bstr(tag)] = ""
+			continue
+		}
+		p.tags[bstr(tag[:eq])] = bstr(unescapeTag(tag[eq+1:]))
+	}
+	return p.tags
+}
+
+// unescapeTag unescapes a tag value in place
+func unescapeTag(v []byte) []byte {
+	w := 0
+	for r := 0; r < len(v); r++ {
+		c := v[r]
+		if c == '\\' {
+			r++
+			if r == len(v) {
+				break
+			}
+			switch c = v[r]; c {
+			case ':':
+				c = ';'
+			case 's':
+				c = ' '
+			case 'r':
+				c = '\r'
+			case 'n':
+				c = '\n'
+			}
+		}
+		v[w] = c
+		w++
+	}
+	return v[:w]
+}
+
+func indexSpace(b []byte) int {
+	i := 0
+	for i < len(b) && b[i] != ' ' {
+		i++
+	}
+	return i
+}
+
+func skipSpaces(b []byte) []byte {
+	for len(b) > 0 && b[0] == ' ' {
+		b = b[1:]
+	}
+	return b
+}
+
+// Clone returns a copy of m with its own strings, Parms, Tags and Raw
+func (m Message) Clone() Message {
+	c := m
+	c.Prefix = Prefix{
+		Nick: strings.Clone(m.Prefix.Nick),
+		User: strings.Clone(m.Prefix.User),
+		Host: strings.Clone(m.Prefix.Host),
+	}
+	c.Command = strings.Clone(m.Command)
+	c.Trailing = strings.Clone(m.Trailing)
+	if m.Parms != nil {
+		c.Parms = make(Parms, len(m.Parms))
+		for i, s := range m.Parms {
+			c.Parms[i] = strings.Clone(s)
+		}
+	}
+	if m.Tags != nil {
+		c.Tags = make(Tags, len(m.Tags))
+		for k, v := range m.Tags {
+			c.Tags[strings.Clone(k)] = strings.Clone(v)
+		}
+	}
+	if m.Raw != nil {
+		c.Raw = append([]byte(nil), m.Raw...)
+	}
+	return c
+}
@@ -0,0 +1,336 @@
+package irc
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultISONInterval is the default interval between ISON queries
+const DefaultISONInterval = time.Minute
+
+// maxISONParms is the number of nicks send in one ISON query
+const maxISONParms = 15
+
+// maxMonitorLength is the maximal length of the targets of one MONITOR
+const maxMonitorLength = 400
+
+// PresenceEvent is emitted on Client.Events when a watched nick comes online
+// or goes offline
+type PresenceEvent struct {
+	Nick   string
+	Prefix Prefix // only set by MONITOR
+	Online bool
+}
+
+type watch struct {
+	nick    string
+	online  bool
+	monitor bool // watched with MONITOR instead of ISON
+}
+
+// Presence watches if nicks are online. It uses MONITOR if the server
+// supports it and falls back to periodic ISON queries for servers without
+// MONITOR or nicks exceeding the MONITOR limit. Presence must be added to the
+// Handler chain of the Client.
+type Presence struct {
+	// Interval between ISON queries, defaults to DefaultISONInterval
+	Interval       time.Duration
+	DefaultHandler Handler
+
+	cl        *Client
+	mu        sync.Mutex
+	watched   map[string]*watch // by lower case nick
+	monitored int
+	pending   [][]string // ISON queries waiting for a reply
+	stop      chan struct{}
+}
+
+// NewPresence returns a new Presence watching nicks on Client
+func NewPresence(cl *Client, nicks ...string) *Presence {
+	p := &Presence{
+		DefaultHandler: defaultHandler,
+		cl:             cl,
+		watched:        make(map[string]*watch),
+	}
+	for _, nick := range nicks {
+		p.watched[strings.ToLower(nick)] = &watch{nick: nick}
+	}
+	return p
+}
+
+// Start starts watching, call it once the Client is registered
+func (p *Presence) Start() {
+	p.mu.Lock()
+	if p.stop == nil {
+		p.stop = make(chan struct{})
+		go p.poll(p.stop)
+	}
+	p.mu.Unlock()
+	p.sync()
+}
+
+// Stop stops the ISON queries
+func (p *Presence) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Watch adds nicks to the watched nicks
+func (p *Presence) Watch(nicks ...string) {
+	limit, ok := p.monitorLimit()
+	var add []string
+	p.mu.Lock()
+	for _, nick := range nicks {
+		key := strings.ToLower(nick)
+		if _, ok := p.watched[key]; ok {
+			continue
+		}
+		w := &watch{nick: nick}
+		if ok && (limit == 0 || p.monitored < limit) {
+			w.monitor = true
+			p.monitored++
+			add = append(add, nick)
+		}
+		p.watched[key] = w
+	}
+	p.mu.Unlock()
+	p.monitor("+", add)
+}
+
+// Unwatch removes nicks from the watched nicks
+func (p *Presence) Unwatch(nicks ...string) {
+	var del []string
+	p.mu.Lock()
+	for _, nick := range nicks {
+		key := strings.ToLower(nick)
+		if w, ok := p.watched[key]; ok {
+			if w.monitor {
+				p.monitored--
+				del = append(del, w.nick)
+			}
+			delete(p.watched, key)
+		}
+	}
+	p.mu.Unlock()
+	p.monitor("-", del)
+}
+
+// Online reports if the watched nick is online
+func (p *Presence) Online(nick string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w, ok := p.watched[strings.ToLower(nick)]
+	return ok && w.online
+}
+
+// monitorLimit returns the MONITOR limit of the server, 0 means unlimited.
+// ok is false if the server does not support MONITOR.
+func (p *Presence) monitorLimit() (limit int, ok bool) {
+	is := p.cl.ISupport()
+	if _, ok := is["MONITOR"]; !ok {
+		return 0, false
+	}
+	return is.Int("MONITOR"), true
+}
+
+// sync (re)sends the MONITOR list, the server clears it on reconnect
+func (p *Presence) sync() {
+	limit, ok := p.monitorLimit()
+	var add []string
+	p.mu.Lock()
+	p.monitored = 0
+	p.pending = nil
+	for _, w := range p.watched {
+		w.monitor = ok && (limit == 0 || p.monitored < limit)
+		if w.monitor {
+			p.monitored++
+			add = append(add, w.nick)
+		}
+	}
+	p.mu.Unlock()
+	p.monitor("+", add)
+	p.ison()
+}
+
+// monitor sends "MONITOR <op> <nick>{,<nick>}" in lines of a sane length
+func (p *Presence) monitor(op string, nicks []string) {
+	for len(nicks) > 0 {
+		targets := nicks[0]
+		nicks = nicks[1:]
+		for len(nicks) > 0 && len(targets)+len(",")+len(nicks[0]) < maxMonitorLength {
+			targets += "," + nicks[0]
+			nicks = nicks[1:]
+		}
+		p.cl.Send(Message{
+			Command: "MONITOR",
+			Parms:   Parms{op, targets},
+		})
+	}
+}
+
+func (p *Presence) poll(stop chan struct{}) {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultISONInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.ison()
+		case <-stop:
+			return
+		case <-p.cl.Done:
+			return
+		}
+	}
+}
+
+// ison queries the nicks not watched by MONITOR
+func (p *Presence) ison() {
+	var queries [][]string
+	var query []string
+	p.mu.Lock()
+	for _, w := range p.watched {
+		if w.monitor {
+			continue
+		}
+		query = append(query, w.nick)
+		if len(query) == maxISONParms {
+			queries = append(queries, query)
+			query = nil
+		}
+	}
+	if len(query) > 0 {
+		queries = append(queries, query)
+	}
+	p.mu.Unlock()
+
+	for _, q := range queries {
+		// pending before sending, the reply may come before Send returns
+		p.mu.Lock()
+		p.pending = append(p.pending, q)
+		p.mu.Unlock()
+		if err := p.cl.Send(Message{Command: "ISON", Parms: Parms(q)}); err != nil {
+			p.unpend(q)
+		}
+	}
+}
+
+// unpend removes the ISON query q that was not sent from pending
+func (p *Presence) unpend(q []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, pq := range p.pending {
+		if &pq[0] == &q[0] {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// answers reports if the RPL_ISON reply names only nicks of query, a reply
+// to an ISON of the user does not
+func answers(reply string, query []string) bool {
+	asked := make(map[string]bool, len(query))
+	for _, nick := range query {
+		asked[strings.ToLower(nick)] = true
+	}
+	for _, nick := range strings.Fields(reply) {
+		if !asked[strings.ToLower(nick)] {
+			return false
+		}
+	}
+	return true
+}
+
+// set updates the state of nick and emits a PresenceEvent if it changed
+func (p *Presence) set(nick string, prefix Prefix, online bool) {
+	p.mu.Lock()
+	w, ok := p.watched[strings.ToLower(nick)]
+	changed := ok && w.online != online
+	if changed {
+		w.online = online
+	}
+	p.mu.Unlock()
+	if changed {
+		p.cl.emit(PresenceEvent{Nick: nick, Prefix: prefix, Online: online})
+	}
+}
+
+func (p *Presence) presenceControl(req Message) bool {
+	switch req.Command {
+	case RplMONONLINE, RplMONOFFLINE:
+		for _, t := range strings.Split(req.Trailing, ",") {
+			if t == "" {
+				continue
+			}
+			prefix, nick := ParsePrefix(t), t
+			if prefix.Nick != "" {
+				nick = prefix.Nick
+			} else {
+				prefix = Prefix{}
+			}
+			p.set(nick, prefix, req.Command == RplMONONLINE)
+		}
+		return true
+
+	case ErrMONLISTFULL:
+		// fall back to ISON for the targets that did not fit
+		if len(req.Parms) < 3 {
+			return true
+		}
+		p.mu.Lock()
+		for _, nick := range strings.Split(req.Parms[2], ",") {
+			if w, ok := p.watched[strings.ToLower(nick)]; ok && w.monitor {
+				w.monitor = false
+				p.monitored--
+			}
+		}
+		p.mu.Unlock()
+		return true
+
+	case RplISON:
+		p.mu.Lock()
+		if len(p.pending) == 0 || !answers(req.Trailing, p.pending[0]) {
+			p.mu.Unlock()
+			return false
+		}
+		query := p.pending[0]
+		p.pending = p.pending[1:]
+		p.mu.Unlock()
+
+		online := make(map[string]bool)
+		for _, nick := range strings.Fields(req.Trailing) {
+			online[strings.ToLower(nick)] = true
+		}
+		for _, nick := range query {
+			p.set(nick, Prefix{}, online[strings.ToLower(nick)])
+		}
+		return true
+
+	case RplENDOFMOTD, ErrNOMOTD:
+		// registered after a reconnect
+		p.mu.Lock()
+		started := p.stop != nil
+		p.mu.Unlock()
+		if started {
+			p.sync()
+		}
+	}
+	return false
+}
+
+// ServeIRC implaments Handler
+func (p *Presence) ServeIRC(req Message, res chan<- Message) bool {
+	if p.presenceControl(req) {
+		return true
+	}
+	return p.DefaultHandler.ServeIRC(req, res)
+}
@@ -0,0 +1,162 @@
+package irc
+
+import (
+	"log"
+	"strings"
+)
+
+// Priority is the send priority class of an outgoing Message
+type Priority int
+
+// Priority classes, Messages of a lower class are always sent first
+const (
+	PriorityHigh   Priority = iota // PONG and connection registration
+	PriorityNormal                 // commands
+	PriorityBulk                   // PRIVMSG and NOTICE
+)
+
+// DefaultSendQueue is the default number of bulk Messages queued per target
+const DefaultSendQueue = 16
+
+// MessagePriority returns the Priority class of m
+func MessagePriority(m Message) Priority {
+	switch m.Command {
+	case "PONG", "PING", "PASS", "CAP", "AUTHENTICATE", "NICK", "USER":
+		return PriorityHigh
+	case "PRIVMSG", "NOTICE", "BATCH":
+		return PriorityBulk
+	default:
+		return PriorityNormal
+	}
+}
+
+// fairQueue holds a FIFO per target and serves the targets round-robin
+type fairQueue struct {
+	targets map[string][]Message
+	order   []string
+	n       int
+}
+
+func (q *fairQueue) push(target string, m Message) {
+	if q.targets == nil {
+		q.targets = make(map[string][]Message)
+	}
+	if _, ok := q.targets[target]; !ok {
+		q.order = append(q.order, target)
+	}
+	q.targets[target] = append(q.targets[target], m)
+	q.n++
+}
+
+func (q *fairQueue) pop() (Message, bool) {
+	if q.n == 0 {
+		return Message{}, false
+	}
+	t := q.order[0]
+	ms := q.targets[t]
+	m := ms[0]
+	if len(ms) == 1 {
+		delete(q.targets, t)
+		q.order = q.order[1:]
+	} else {
+		q.targets[t] = ms[1:]
+		q.order = append(q.order[1:], t)
+	}
+	q.n--
+	return m, true
+}
+
+// sendQueue orders outgoing Messages by Priority and serves the targets of a
+// class round-robin. Bulk Messages to a target that exceeds the limit get
+// coalesced with the last queued Message or the oldest one is dropped.
+// Messages of a BATCH are queued together and never dropped.
+type sendQueue struct {
+	classes [PriorityBulk + 1]fairQueue
+	limit   int
+	dropped int
+	batches map[string]string // target of open BATCHes by reference
+	// budget returns the maximal length of a Trailing to target
+	budget func(command, target string) int
+}
+
+func newSendQueue(limit int, budget func(command, target string) int) *sendQueue {
+	if limit <= 0 {
+		limit = DefaultSendQueue
+	}
+	return &sendQueue{
+		limit:   limit,
+		batches: make(map[string]string),
+		budget:  budget,
+	}
+}
+
+// Len returns the number of queued Messages
+func (q *sendQueue) Len() int {
+	var n int
+	for i := range q.classes {
+		n += q.classes[i].n
+	}
+	return n
+}
+
+// Push adds m to the queue
+func (q *sendQueue) Push(m Message) {
+	prio := MessagePriority(m)
+	var target string
+	if prio != PriorityHigh && len(m.Parms) > 0 {
+		target = m.Parms[0]
+	}
+	if m.Command == "BATCH" && len(m.Parms) > 0 && m.Parms[0] != "" {
+		// "BATCH +ref type target" and "BATCH -ref"
+		ref := m.Parms[0][1:]
+		if strings.HasPrefix(m.Parms[0], "+") {
+			if len(m.Parms) > 2 {
+				target = m.Parms[2]
+			}
+			q.batches[ref] = target
+		} else {
+			target = q.batches[ref]
+			delete(q.batches, ref)
+		}
+	}
+
+	cq := &q.classes[prio]
+	if prio == PriorityBulk && !isBatched(m) && len(cq.targets[target]) >= q.limit {
+		ms := cq.targets[target]
+		last := &ms[len(ms)-1]
+		// CTCPs are kept as they are
+		if last.Command == m.Command && last.Parms.String() == m.Parms.String() &&
+			len(last.Tags) == 0 && len(m.Tags) == 0 &&
+			!strings.HasPrefix(last.Trailing, "\x01") && !strings.HasPrefix(m.Trailing, "\x01") &&
+			len(last.Trailing)+len(" ")+len(m.Trailing) <= q.budget(m.Command, target) {
+			last.Trailing += " " + m.Trailing
+			return
+		}
+		for i := range ms {
+			if !isBatched(ms[i]) {
+				cq.targets[target] = append(ms[:i:i], ms[i+1:]...)
+				cq.n--
+				q.dropped++
+				log.Printf("send queue for %q full, dropped %d messages", target, q.dropped)
+				break
+			}
+		}
+	}
+	cq.push(target, m)
+}
+
+// isBatched reports if m is part of an outgoing BATCH
+func isBatched(m Message) bool {
+	_, ok := m.Tags["batch"]
+	return ok || m.Command == "BATCH"
+}
+
+// Pop removes and returns the next Message to send
+func (q *sendQueue) Pop() (Message, bool) {
+	for i := range q.classes {
+		if m, ok := q.classes[i].pop(); ok {
+			return m, true
+		}
+	}
+	return Message{}, false
+}
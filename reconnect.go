@@ -0,0 +1,114 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// Defaults of ReconnectPolicy
+const (
+	DefaultReconnectMinDelay = time.Second
+	DefaultReconnectMaxDelay = 5 * time.Minute
+	DefaultReconnectJitter   = 0.2
+)
+
+// ReconnectPolicy controls the reconnects after the connection was lost, zero
+// values select the defaults
+type ReconnectPolicy struct {
+	// MinDelay is the delay before the first attempt, it doubles with every
+	// failed attempt up to MaxDelay
+	MinDelay, MaxDelay time.Duration
+	// Jitter randomizes the delays by up to ±Jitter (0.2 is ±20%), a negative
+	// value disables it
+	Jitter float64
+	// MaxAttempts is the number of attempts before giving up, 0 never gives up
+	MaxAttempts int
+}
+
+// DisconnectEvent is emitted when the connection to Address is lost
+type DisconnectEvent struct {
+	Address string
+	Err     error
+}
+
+// RetryEvent is emitted before the reconnect attempt to Address after Delay
+type RetryEvent struct {
+	Address string
+	Attempt int
+	Delay   time.Duration
+}
+
+// ReconnectEvent is emitted after the reconnect to Address succeeded
+type ReconnectEvent struct {
+	Address  string
+	Attempts int
+}
+
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.MinDelay <= 0 {
+		p.MinDelay = DefaultReconnectMinDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultReconnectMaxDelay
+	}
+	if p.MaxDelay < p.MinDelay {
+		p.MaxDelay = p.MinDelay
+	}
+	if p.Jitter == 0 {
+		p.Jitter = DefaultReconnectJitter
+	}
+	return p
+}
+
+// delay returns the delay before attempt (starting at 1)
+func (p ReconnectPolicy) delay(attempt int) time.Duration {
+	d := p.MinDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// reconnect closes the connection and connects again following the
+// ReconnectPolicy, every failed attempt rotates to the next server
+func (c *Client) reconnect(cause error) error {
+	c.stopConn()
+	c.emit(DisconnectEvent{Address: c.address, Err: cause})
+	if len(c.servers) == 0 {
+		// NewClient has nothing to redial
+		return fmt.Errorf("connection lost: %w", cause)
+	}
+
+	p := c.cfg.Reconnect
+	var err error
+	for attempt := 1; p.MaxAttempts == 0 || attempt <= p.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			c.server = (c.server + 1) % len(c.servers)
+			c.address = c.servers[c.server]
+		}
+		d := p.delay(attempt)
+		c.emit(RetryEvent{Address: c.address, Attempt: attempt, Delay: d})
+		select {
+		case <-time.After(d):
+		case <-c.quitting:
+			return ErrClosed
+		case <-c.ctx.Done():
+			return ErrClosed
+		}
+
+		if err = c.connect(); err == nil {
+			c.emit(ReconnectEvent{Address: c.address, Attempts: attempt})
+			return nil
+		}
+		log.Print(err)
+	}
+	return fmt.Errorf("giving up after %d reconnect attempts: %w", p.MaxAttempts, err)
+}
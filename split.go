@@ -0,0 +1,153 @@
+package irc
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLineLength is the maximal length of a Message without tags including the "\r\n"
+	MaxLineLength = 512
+
+	// worst case lengths of our user and host while our prefix is unknown
+	maxUserLen = 10
+	maxHostLen = 63
+)
+
+// SplitText splits text into pieces of at most max bytes. It splits at spaces
+// where possible and never inside a UTF-8 encoded rune or a formatting code.
+func SplitText(text string, max int) []string {
+	return splitText(text, max, false)
+}
+
+// splitText splits like SplitText, with keepSpace the spaces at the splits are
+// kept at the start of the next piece so the pieces can be concatenated again
+func splitText(text string, max int, keepSpace bool) []string {
+	var lines []string
+	for len(text) > max {
+		cut, space := 0, -1
+		for i := 0; i < len(text); {
+			n := atomLen(text[i:])
+			if i+n > max {
+				break
+			}
+			if text[i] == ' ' && i > 0 {
+				space = i
+			}
+			i += n
+			cut = i
+		}
+		if cut > 0 && cut < len(text) && text[cut] == ' ' {
+			space = cut
+		}
+
+		switch {
+		case space > 0:
+			lines = append(lines, text[:space])
+			text = text[space:]
+			if !keepSpace {
+				text = text[len(" "):]
+			}
+		case cut > 0:
+			lines = append(lines, text[:cut])
+			text = text[cut:]
+		default:
+			// max is smaller than a single rune or formatting code
+			n := atomLen(text)
+			lines = append(lines, text[:n])
+			text = text[n:]
+		}
+	}
+	return append(lines, text)
+}
+
+// atomLen returns the length of the rune or formatting code at the start of s
+func atomLen(s string) int {
+	switch s[0] {
+	case '\x03': // color: \x03[fg[,bg]] with up to 2 digits each
+		n := 1 + countDigits(s[1:], 2, isDigit)
+		if n > 1 && n+1 < len(s) && s[n] == ',' && isDigit(s[n+1]) {
+			n += 1 + countDigits(s[n+1:], 2, isDigit)
+		}
+		return n
+	case '\x04': // hex color: \x04[rrggbb[,rrggbb]] with all 6 digits like format
+		if countDigits(s[1:], 6, isHex) < 6 {
+			return 1
+		}
+		if len(s) > 7 && s[7] == ',' && countDigits(s[8:], 6, isHex) == 6 {
+			return 14
+		}
+		return 7
+	}
+	if s[0] < utf8.RuneSelf {
+		return 1
+	}
+	_, n := utf8.DecodeRuneInString(s)
+	return n
+}
+
+func countDigits(s string, max int, is func(byte) bool) int {
+	var n int
+	for n < max && n < len(s) && is(s[n]) {
+		n++
+	}
+	return n
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// textBudget returns the number of bytes left for the Trailing of a command
+// to target after the server added our prefix
+func (c *Client) textBudget(command, target string) int {
+	c.mu.Lock()
+	p, nick := c.prefix, c.nick
+	c.mu.Unlock()
+
+	prefixLen := len(p.String())
+	if p.Host == "" {
+		prefixLen = len(nick) + len("!") + maxUserLen + len("@") + maxHostLen
+	}
+	return MaxLineLength - len(":"+" ") - prefixLen - len(command+" "+target+" :") - len("\r\n")
+}
+
+// say splits text into lines and pieces that fit into the line length and
+// sends them as command to target, empty lines are skipped
+func (c *Client) say(command, target, text string) error {
+	max := c.textBudget(command, target)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			// servers reject it with ERR_NOTEXTTOSEND
+			continue
+		}
+		for _, s := range SplitText(line, max) {
+			if err := c.Send(Message{
+				Command:     command,
+				Parms:       Parms{target},
+				Trailing:    s,
+				HasTrailing: true,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Say sends text as PRIVMSG to target (channel/nick). Text gets split at
+// newlines and into pieces that fit into the line length, empty lines are
+// not sent.
+func (c *Client) Say(target, text string) error {
+	return c.say("PRIVMSG", target, text)
+}
+
+// Notice sends text as NOTICE to target (channel/nick). Text gets split like in Say.
+func (c *Client) Notice(target, text string) error {
+	return c.say("NOTICE", target, text)
+}
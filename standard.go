@@ -0,0 +1,63 @@
+package irc
+
+import "strings"
+
+// StandardReply is an IRCv3 standard reply
+// "FAIL|WARN|NOTE <command> <code> [<context>...] :<description>".
+// A FAIL is returned as error by SendAndWait, use errors.Is with a
+// StandardReply holding only Code (and Command) to check it.
+type StandardReply struct {
+	Type        string // FAIL, WARN or NOTE
+	Command     string // the command it is about or "*"
+	Code        string // e.g. "ACCOUNT_REQUIRED"
+	Context     []string
+	Description string
+}
+
+// StandardReplyEvent is emitted for every standard reply not answering a
+// SendAndWait
+type StandardReplyEvent struct {
+	StandardReply
+	Message Message
+}
+
+// ParseStandardReply returns the StandardReply of a FAIL, WARN or NOTE Message
+func ParseStandardReply(m Message) (*StandardReply, bool) {
+	switch m.Command {
+	case "FAIL", "WARN", "NOTE":
+	default:
+		return nil, false
+	}
+	ps := m.Params()
+	if len(ps) < 3 {
+		return nil, false
+	}
+	r := &StandardReply{
+		Type:        m.Command,
+		Command:     ps[0],
+		Code:        ps[1],
+		Description: ps[len(ps)-1],
+	}
+	if len(ps) > 3 {
+		r.Context = append([]string(nil), ps[2:len(ps)-1]...)
+	}
+	return r, true
+}
+
+func (r *StandardReply) Error() string {
+	str := r.Type + " " + r.Command + " " + r.Code
+	if len(r.Context) > 0 {
+		str += " " + strings.Join(r.Context, " ")
+	}
+	return str + ": " + r.Description
+}
+
+// Is reports if target is a *StandardReply with the same Code and, if set in
+// target, the same Type and Command
+func (r *StandardReply) Is(target error) bool {
+	t, ok := target.(*StandardReply)
+	if !ok || t.Code != r.Code {
+		return false
+	}
+	return (t.Type == "" || t.Type == r.Type) && (t.Command == "" || t.Command == r.Command)
+}
@@ -0,0 +1,115 @@
+package irc
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxTagsLength is the maximal length of the tags of a Message including
+	// the "@" and the separating space
+	MaxTagsLength = 8191
+
+	forbidden = "\x00\r\n"
+)
+
+// commandParms are the parameter limits of single commands
+var commandParms = map[string]int{
+	"USERHOST": 5, // RFC 2812 4.8
+}
+
+// ValidationError is returned by Validate and Client.Send for a Message that
+// can not be send as it is
+type ValidationError struct {
+	Message Message
+	Reason  string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid " + strconv.Quote(e.Message.Command) + " message: " + e.Reason
+}
+
+// Validate checks that m is a valid Message that is send as the single line
+// it represents. It checks the command syntax, the parameter rules, the line
+// length, the parameter limits of commands like USERHOST and that no field
+// contains NUL, CR or LF.
+func (m Message) Validate() error {
+	invalid := func(reason string) error {
+		return &ValidationError{Message: m, Reason: reason}
+	}
+
+	if !validCommand(m.Command) {
+		return invalid("command must be letters or a 3 digit numeric")
+	}
+	if strings.ContainsAny(m.Prefix.String(), forbidden+" ") {
+		return invalid("prefix contains a space, NUL, CR or LF")
+	}
+	for k := range m.Tags {
+		if !validTagKey(k) {
+			return invalid("invalid tag key " + strconv.Quote(k))
+		}
+	}
+
+	ps := m.Params()
+	if len(ps) > maxParms {
+		return invalid("more than " + strconv.Itoa(maxParms) + " parameters")
+	}
+	if max, ok := commandParms[m.Command]; ok && len(ps) > max {
+		return invalid("more than " + strconv.Itoa(max) + " parameters")
+	}
+	last := len(m.Parms) - 1
+	if m.HasTrailing || m.Trailing != "" {
+		last = len(m.Parms)
+	}
+	for i, p := range ps {
+		if strings.ContainsAny(p, forbidden) {
+			return invalid("parameter contains NUL, CR or LF")
+		}
+		if i < last && needsTrailing(p) {
+			return invalid("parameter " + strconv.Quote(p) + " is empty, starts with ':' or contains a space")
+		}
+	}
+
+	str := m.String()
+	tagsLen := 0
+	if len(m.Tags) > 0 {
+		tagsLen = len("@" + m.Tags.String() + " ")
+	}
+	if tagsLen > MaxTagsLength {
+		return invalid("tags exceed " + strconv.Itoa(MaxTagsLength) + " bytes")
+	}
+	if len(str)-tagsLen > MaxLineLength {
+		return invalid("line exceeds " + strconv.Itoa(MaxLineLength) + " bytes")
+	}
+	return nil
+}
+
+func validCommand(cmd string) bool {
+	if len(cmd) == 3 && isDigit(cmd[0]) && isDigit(cmd[1]) && isDigit(cmd[2]) {
+		return true
+	}
+	if cmd == "" {
+		return false
+	}
+	for i := 0; i < len(cmd); i++ {
+		if c := cmd[i]; !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return false
+		}
+	}
+	return true
+}
+
+// validTagKey reports if k is a valid tag key "[+][vendor/]name"
+func validTagKey(k string) bool {
+	k = strings.TrimPrefix(k, "+")
+	if k == "" {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c) || c == '-' || c == '/' || c == '.') {
+			return false
+		}
+	}
+	return true
+}